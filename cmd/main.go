@@ -3,14 +3,18 @@ package main
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"os/signal"
 	"path"
 	"runtime"
+	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/Hymiside/lamoda-api/pkg/handler"
 	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/Hymiside/lamoda-api/pkg/notifier"
 	"github.com/Hymiside/lamoda-api/pkg/repository"
 	"github.com/Hymiside/lamoda-api/pkg/server"
 	"github.com/Hymiside/lamoda-api/pkg/service"
@@ -18,6 +22,9 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// webhookTimeout limits the time of a low stock webhook request.
+const webhookTimeout = 10 * time.Second
+
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -47,10 +54,90 @@ func main() {
 	if err != nil {
 		log.Fatalf("error to connect postgres: %v", err)
 	}
+	reservationTTL, err := time.ParseDuration(os.Getenv("RESERVATION_TTL"))
+	if err == nil && reservationTTL <= 0 {
+		err = fmt.Errorf("ttl must be positive")
+	}
+	if err != nil {
+		log.Fatalf("error to parse RESERVATION_TTL: %v", err)
+	}
+
+	expirationInterval, err := time.ParseDuration(os.Getenv("RESERVATION_EXPIRATION_INTERVAL"))
+	if err == nil && expirationInterval <= 0 {
+		err = fmt.Errorf("interval must be positive")
+	}
+	if err != nil {
+		log.Fatalf("error to parse RESERVATION_EXPIRATION_INTERVAL: %v", err)
+	}
+
+	quoteTTL, err := time.ParseDuration(os.Getenv("RESERVATION_QUOTE_TTL"))
+	if err == nil && quoteTTL <= 0 {
+		err = fmt.Errorf("ttl must be positive")
+	}
+	if err != nil {
+		log.Fatalf("error to parse RESERVATION_QUOTE_TTL: %v", err)
+	}
+
+	quoteSecret := os.Getenv("RESERVATION_QUOTE_SECRET")
+	if quoteSecret == "" {
+		log.Fatalf("RESERVATION_QUOTE_SECRET is not set")
+	}
+
+	extraDistance := floatEnv("ALLOCATION_EXTRA_DISTANCE", models.DefaultExtraDistance)
+	reservationConfig := models.ConfigReservation{
+		TTL:                reservationTTL,
+		ExpirationInterval: expirationInterval,
+		QuoteTTL:           quoteTTL,
+		QuoteSecret:        []byte(quoteSecret),
+		Strategy:           models.AllocationStrategy(os.Getenv("ALLOCATION_STRATEGY")),
+		ShipmentCost:       floatEnv("ALLOCATION_SHIPMENT_COST", models.DefaultShipmentCost),
+		UnitCostPerKm:      floatEnv("ALLOCATION_UNIT_COST_PER_KM", models.DefaultUnitCostPerKm),
+		ExtraDistance:      &extraDistance,
+	}
+	if reservationConfig.Strategy == "" {
+		reservationConfig.Strategy = models.StrategyNearest
+	}
+	if _, err = service.NewAllocator(reservationConfig.Strategy, reservationConfig); err != nil {
+		log.Fatalf("error to parse ALLOCATION_STRATEGY: %v", err)
+	}
+
+	lowStockInterval, err := time.ParseDuration(os.Getenv("LOW_STOCK_EVALUATION_INTERVAL"))
+	if err == nil && lowStockInterval <= 0 {
+		err = fmt.Errorf("interval must be positive")
+	}
+	if err != nil {
+		log.Fatalf("error to parse LOW_STOCK_EVALUATION_INTERVAL: %v", err)
+	}
+
+	var lowStockNotifier interface {
+		NotifyLowStock(ctx context.Context, alerts []models.LowStockAlert) error
+	}
+	switch os.Getenv("LOW_STOCK_NOTIFIER") {
+	case "", "log":
+		lowStockNotifier = notifier.NewLog()
+	case "webhook":
+		webhookURL := os.Getenv("LOW_STOCK_WEBHOOK_URL")
+		if webhookURL == "" {
+			log.Fatalf("LOW_STOCK_WEBHOOK_URL is not set")
+		}
+		lowStockNotifier = notifier.NewWebhook(webhookURL, webhookTimeout)
+	default:
+		log.Fatalf("unknown LOW_STOCK_NOTIFIER %q", os.Getenv("LOW_STOCK_NOTIFIER"))
+	}
+
+	idempotencyConfig := models.ConfigIdempotency{
+		Lease: durationEnv("IDEMPOTENCY_KEY_LEASE", models.DefaultIdempotencyLease),
+		TTL:   durationEnv("IDEMPOTENCY_KEY_TTL", models.DefaultIdempotencyTTL),
+	}
+
 	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
+	services := service.NewService(repos, reservationConfig, idempotencyConfig, lowStockNotifier)
 	handlers := handler.NewHandler(services)
 
+	go services.RunReservationsExpiration(ctx)
+	go services.RunLowStockEvaluation(ctx, lowStockInterval)
+	go services.RunIdempotencyKeysPurge(ctx)
+
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
@@ -69,3 +156,37 @@ func main() {
 		log.Fatalf("error to start server: %v", err)
 	}
 }
+
+// durationEnv parses the positive duration of the environment variable name, def is used if it is not set.
+func durationEnv(name string, def time.Duration) time.Duration {
+	val := os.Getenv(name)
+	if val == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(val)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("duration must be positive")
+	}
+	if err != nil {
+		log.Fatalf("error to parse %s: %v", name, err)
+	}
+	return d
+}
+
+// floatEnv parses the finite non-negative number of the environment variable name, def is used if it is not set.
+func floatEnv(name string, def float64) float64 {
+	val := os.Getenv(name)
+	if val == "" {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err == nil && (f < 0 || math.IsNaN(f) || math.IsInf(f, 0)) {
+		err = fmt.Errorf("number must be finite and not negative")
+	}
+	if err != nil {
+		log.Fatalf("error to parse %s: %v", name, err)
+	}
+	return f
+}
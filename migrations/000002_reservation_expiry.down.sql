@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS reserved_products_expires_at_idx;
+ALTER TABLE reserved_products DROP COLUMN IF EXISTS expires_at;
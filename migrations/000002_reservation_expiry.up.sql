@@ -0,0 +1,4 @@
+-- status 3 - expired
+ALTER TABLE reserved_products ADD COLUMN expires_at TIMESTAMP;
+
+CREATE INDEX reserved_products_expires_at_idx ON reserved_products (expires_at) WHERE status = 0;
@@ -0,0 +1 @@
+ALTER TABLE reserved_products DROP COLUMN IF EXISTS distance;
@@ -0,0 +1 @@
+ALTER TABLE reserved_products ADD COLUMN distance DOUBLE PRECISION;
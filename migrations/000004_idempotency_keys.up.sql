@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys (
+    key TEXT NOT NULL,
+    scope TEXT NOT NULL, -- method and path of the request
+    request_hash TEXT NOT NULL,
+    status_code INT, -- NULL while the request is in progress
+    content_type TEXT,
+    response BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (key, scope)
+);
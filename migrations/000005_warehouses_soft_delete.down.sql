@@ -0,0 +1 @@
+ALTER TABLE warehouses DROP COLUMN IF EXISTS deleted_at;
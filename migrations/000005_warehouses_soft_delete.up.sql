@@ -0,0 +1 @@
+ALTER TABLE warehouses ADD COLUMN deleted_at TIMESTAMP;
@@ -0,0 +1,2 @@
+ALTER TABLE products DROP CONSTRAINT IF EXISTS non_negative_dimensions;
+ALTER TABLE products DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN archived_at TIMESTAMP;
+ALTER TABLE products ADD CONSTRAINT non_negative_dimensions CHECK (width >= 0 AND height >= 0 AND depth >= 0);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS products_title_id_idx;
+DROP INDEX IF EXISTS products_title_trgm_idx;
+DROP INDEX IF EXISTS products_part_number_pattern_idx;
@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS "pg_trgm";
+
+CREATE INDEX products_part_number_pattern_idx ON products (part_number text_pattern_ops);
+CREATE INDEX products_title_trgm_idx ON products USING GIN (title gin_trgm_ops);
+CREATE INDEX products_title_id_idx ON products (title, id);
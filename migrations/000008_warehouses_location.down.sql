@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS warehouses_location_idx;
+ALTER TABLE warehouses DROP COLUMN IF EXISTS location;
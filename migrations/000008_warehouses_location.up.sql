@@ -0,0 +1,4 @@
+ALTER TABLE warehouses ADD COLUMN location GEOGRAPHY(Point, 4326)
+    GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(lng, lat), 4326)::geography) STORED;
+
+CREATE INDEX warehouses_location_idx ON warehouses USING GIST (location);
@@ -0,0 +1,26 @@
+-- stock_movements is the ledger of changes of warehouse_products.quantity. Quantity is the signed change,
+-- confirm movements do not change the quantity which is taken from warehouses by reserve movements.
+CREATE TABLE stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL,
+    warehouse_product_id INTEGER NOT NULL,
+    kind TEXT NOT NULL CONSTRAINT known_kind CHECK (kind IN ('reserve', 'release', 'confirm', 'return', 'receipt', 'adjustment', 'transfer')),
+    quantity INTEGER NOT NULL,
+    reservation_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id),
+    FOREIGN KEY (warehouse_product_id) REFERENCES warehouse_products(id)
+);
+
+CREATE INDEX stock_movements_warehouse_id_idx ON stock_movements (warehouse_id, created_at);
+
+CREATE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
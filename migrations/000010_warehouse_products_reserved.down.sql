@@ -0,0 +1,14 @@
+ALTER TABLE stock_movements DISABLE TRIGGER stock_movements_append_only;
+
+ALTER TABLE stock_movements ADD COLUMN quantity INTEGER NOT NULL DEFAULT 0;
+UPDATE stock_movements SET quantity = on_hand - reserved;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS on_hand;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS reserved;
+
+ALTER TABLE stock_movements ENABLE TRIGGER stock_movements_append_only;
+
+ALTER TABLE warehouse_products DROP COLUMN IF EXISTS available;
+ALTER TABLE warehouse_products DROP CONSTRAINT IF EXISTS reserved_within_on_hand;
+UPDATE warehouse_products SET on_hand = on_hand - reserved;
+ALTER TABLE warehouse_products DROP COLUMN IF EXISTS reserved;
+ALTER TABLE warehouse_products RENAME COLUMN on_hand TO quantity;
@@ -0,0 +1,35 @@
+-- quantity of warehouse_products was the stock available to reserve, reserved products had already been
+-- taken from it. It is split into the stock on hand and the reserved stock, which is the quantity of reserved
+-- products. Confirmed products leave the stock on hand.
+ALTER TABLE warehouse_products RENAME COLUMN quantity TO on_hand;
+ALTER TABLE warehouse_products ADD COLUMN reserved INTEGER NOT NULL DEFAULT 0;
+
+UPDATE warehouse_products wp
+SET reserved = r.quantity, on_hand = wp.on_hand + r.quantity
+FROM (
+    SELECT warehouse_product_id, SUM(quantity) AS quantity
+    FROM reserved_products
+    WHERE status = 0
+    GROUP BY warehouse_product_id
+) r
+WHERE wp.id = r.warehouse_product_id;
+
+ALTER TABLE warehouse_products ADD CONSTRAINT reserved_within_on_hand CHECK (reserved >= 0 AND reserved <= on_hand);
+ALTER TABLE warehouse_products ADD COLUMN available INTEGER GENERATED ALWAYS AS (on_hand - reserved) STORED;
+
+-- movements record changes of both quantities
+ALTER TABLE stock_movements DISABLE TRIGGER stock_movements_append_only;
+
+ALTER TABLE stock_movements ADD COLUMN on_hand INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE stock_movements ADD COLUMN reserved INTEGER NOT NULL DEFAULT 0;
+
+UPDATE stock_movements SET reserved = -quantity WHERE kind IN ('reserve', 'release');
+UPDATE stock_movements SET on_hand = quantity WHERE kind NOT IN ('reserve', 'release', 'confirm');
+UPDATE stock_movements m
+SET on_hand = -rp.quantity, reserved = -rp.quantity
+FROM reserved_products rp
+WHERE m.kind = 'confirm' AND rp.reservation_id = m.reservation_id AND rp.warehouse_product_id = m.warehouse_product_id;
+
+ALTER TABLE stock_movements DROP COLUMN quantity;
+
+ALTER TABLE stock_movements ENABLE TRIGGER stock_movements_append_only;
@@ -0,0 +1,4 @@
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS receipt_id;
+DROP TABLE IF EXISTS receipt_items;
+DROP TABLE IF EXISTS receipts;
+ALTER TABLE warehouse_products DROP CONSTRAINT IF EXISTS warehouse_products_warehouse_id_product_id_key;
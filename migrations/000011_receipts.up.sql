@@ -0,0 +1,95 @@
+-- warehouse_products could hold several rows of a product in a warehouse. They are merged into the row
+-- with the smallest id before the constraint is added, reserved products and movements of the other rows
+-- are moved to it. The down migration does not split them again.
+CREATE TEMPORARY TABLE merged_warehouse_products AS
+SELECT id, MIN(id) OVER (PARTITION BY warehouse_id, product_id) AS merged_id
+FROM warehouse_products;
+
+UPDATE warehouse_products wp
+SET on_hand = wp.on_hand + d.on_hand, reserved = wp.reserved + d.reserved
+FROM (
+    SELECT m.merged_id, SUM(dwp.on_hand) AS on_hand, SUM(dwp.reserved) AS reserved
+    FROM merged_warehouse_products m
+    JOIN warehouse_products dwp ON dwp.id = m.id
+    WHERE m.id <> m.merged_id
+    GROUP BY m.merged_id
+) d
+WHERE wp.id = d.merged_id;
+
+-- a reservation has a line on every duplicate row, the lines are merged into the one on the smallest id
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM reserved_products rp
+        JOIN merged_warehouse_products m ON m.id = rp.warehouse_product_id
+        GROUP BY rp.reservation_id, m.merged_id
+        HAVING COUNT(DISTINCT rp.status) > 1
+    ) THEN
+        RAISE EXCEPTION 'reserved products of duplicate warehouse_products rows have different statuses';
+    END IF;
+END;
+$$;
+
+CREATE TEMPORARY TABLE merged_reserved_products AS
+SELECT rp.reservation_id, rp.warehouse_product_id,
+    MIN(rp.warehouse_product_id) OVER w AS kept_id,
+    SUM(rp.quantity) OVER w AS quantity
+FROM reserved_products rp
+JOIN merged_warehouse_products m ON m.id = rp.warehouse_product_id
+WINDOW w AS (PARTITION BY rp.reservation_id, m.merged_id);
+
+UPDATE reserved_products rp
+SET quantity = mrp.quantity
+FROM merged_reserved_products mrp
+WHERE rp.reservation_id = mrp.reservation_id AND rp.warehouse_product_id = mrp.warehouse_product_id
+    AND mrp.warehouse_product_id = mrp.kept_id;
+
+DELETE FROM reserved_products rp
+USING merged_reserved_products mrp
+WHERE rp.reservation_id = mrp.reservation_id AND rp.warehouse_product_id = mrp.warehouse_product_id
+    AND mrp.warehouse_product_id <> mrp.kept_id;
+
+UPDATE reserved_products rp
+SET warehouse_product_id = m.merged_id
+FROM merged_warehouse_products m
+WHERE rp.warehouse_product_id = m.id AND m.id <> m.merged_id;
+
+ALTER TABLE stock_movements DISABLE TRIGGER stock_movements_append_only;
+
+UPDATE stock_movements sm
+SET warehouse_product_id = m.merged_id
+FROM merged_warehouse_products m
+WHERE sm.warehouse_product_id = m.id AND m.id <> m.merged_id;
+
+ALTER TABLE stock_movements ENABLE TRIGGER stock_movements_append_only;
+
+DELETE FROM warehouse_products wp
+USING merged_warehouse_products m
+WHERE wp.id = m.id AND m.id <> m.merged_id;
+
+DROP TABLE merged_reserved_products;
+DROP TABLE merged_warehouse_products;
+
+ALTER TABLE warehouse_products ADD CONSTRAINT warehouse_products_warehouse_id_product_id_key UNIQUE (warehouse_id, product_id);
+
+CREATE TABLE receipts (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'received',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id)
+);
+
+CREATE TABLE receipt_items (
+    receipt_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CONSTRAINT positive_quantity CHECK (quantity > 0),
+
+    PRIMARY KEY (receipt_id, product_id),
+    FOREIGN KEY (receipt_id) REFERENCES receipts(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+ALTER TABLE stock_movements ADD COLUMN receipt_id INTEGER REFERENCES receipts(id);
@@ -0,0 +1,3 @@
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS transfer_id;
+DROP TABLE IF EXISTS transfer_items;
+DROP TABLE IF EXISTS transfers;
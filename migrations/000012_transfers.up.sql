@@ -0,0 +1,28 @@
+CREATE TABLE transfers (
+    id SERIAL PRIMARY KEY,
+    source_warehouse_id INTEGER NOT NULL,
+    destination_warehouse_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'created' CONSTRAINT known_status CHECK (status IN ('created', 'in_transit', 'received')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    shipped_at TIMESTAMP,
+    received_at TIMESTAMP,
+
+    CONSTRAINT different_warehouses CHECK (source_warehouse_id <> destination_warehouse_id),
+    FOREIGN KEY (source_warehouse_id) REFERENCES warehouses(id),
+    FOREIGN KEY (destination_warehouse_id) REFERENCES warehouses(id)
+);
+
+-- in-transit quantities are summed by the destination
+CREATE INDEX transfers_in_transit_idx ON transfers (destination_warehouse_id) WHERE status = 'in_transit';
+
+CREATE TABLE transfer_items (
+    transfer_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CONSTRAINT positive_quantity CHECK (quantity > 0),
+
+    PRIMARY KEY (transfer_id, product_id),
+    FOREIGN KEY (transfer_id) REFERENCES transfers(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+ALTER TABLE stock_movements ADD COLUMN transfer_id INTEGER REFERENCES transfers(id);
@@ -0,0 +1,21 @@
+CREATE TABLE adjustments (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL,
+    reason TEXT NOT NULL CONSTRAINT known_reason CHECK (reason IN ('cycle_count', 'damaged', 'lost', 'found', 'correction')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id)
+);
+
+CREATE TABLE adjustment_items (
+    adjustment_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    on_hand INTEGER NOT NULL, -- before the adjustment
+    counted INTEGER NOT NULL CONSTRAINT non_negative_counted CHECK (counted >= 0),
+
+    PRIMARY KEY (adjustment_id, product_id),
+    FOREIGN KEY (adjustment_id) REFERENCES adjustments(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+ALTER TABLE stock_movements ADD COLUMN adjustment_id INTEGER REFERENCES adjustments(id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS low_stock_alerts;
+DROP TABLE IF EXISTS reorder_points;
@@ -0,0 +1,26 @@
+CREATE TABLE reorder_points (
+    warehouse_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    reorder_point INTEGER NOT NULL CONSTRAINT positive_reorder_point CHECK (reorder_point > 0),
+
+    PRIMARY KEY (warehouse_id, product_id),
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE TABLE low_stock_alerts (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    reorder_point INTEGER NOT NULL,
+    available INTEGER NOT NULL,
+    in_transit INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP,
+
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+-- a product of a warehouse has at most one open alert, it is resolved when the stock is replenished
+CREATE UNIQUE INDEX low_stock_alerts_open_idx ON low_stock_alerts (warehouse_id, product_id) WHERE resolved_at IS NULL;
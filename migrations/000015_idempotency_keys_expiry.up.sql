@@ -0,0 +1,2 @@
+-- created_at is the start of the request: in-progress keys are reclaimed after a lease, all keys are purged after a TTL
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
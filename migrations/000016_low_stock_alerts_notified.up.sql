@@ -0,0 +1,4 @@
+ALTER TABLE low_stock_alerts ADD COLUMN notified_at TIMESTAMP;
+
+-- alerts opened before are considered notified, they were notified once right after they were opened
+UPDATE low_stock_alerts SET notified_at = created_at;
@@ -0,0 +1,3 @@
+ALTER TABLE products ALTER COLUMN depth DROP NOT NULL;
+ALTER TABLE products ALTER COLUMN height DROP NOT NULL;
+ALTER TABLE products ALTER COLUMN width DROP NOT NULL;
@@ -0,0 +1,8 @@
+-- products are listed and sorted by dimensions, which are required for new products. Unknown dimensions
+-- of existing products are stored as 0, so they are sorted first and scanned like any other.
+UPDATE products SET width = COALESCE(width, 0), height = COALESCE(height, 0), depth = COALESCE(depth, 0)
+WHERE width IS NULL OR height IS NULL OR depth IS NULL;
+
+ALTER TABLE products ALTER COLUMN width SET NOT NULL;
+ALTER TABLE products ALTER COLUMN height SET NOT NULL;
+ALTER TABLE products ALTER COLUMN depth SET NOT NULL;
@@ -0,0 +1,8 @@
+-- a quote token reserves its plan once. Quotes expire at most RESERVATION_QUOTE_TTL after they are redeemed,
+-- so older rows are purged with the expiration of reservations.
+CREATE TABLE redeemed_quotes (
+    quote_id UUID PRIMARY KEY,
+    redeemed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX redeemed_quotes_redeemed_at_idx ON redeemed_quotes (redeemed_at);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS products_part_number_key;
+
+-- archived products whose part numbers have been taken again get the suffix of their id,
+-- so every part number is unique again
+UPDATE products p
+SET part_number = p.part_number || '-archived-' || p.id
+WHERE p.archived_at IS NOT NULL AND EXISTS (
+    SELECT 1 FROM products o WHERE o.part_number = p.part_number AND o.id <> p.id
+);
+
+ALTER TABLE products ADD CONSTRAINT products_part_number_key UNIQUE (part_number);
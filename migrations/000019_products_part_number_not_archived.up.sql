@@ -0,0 +1,3 @@
+-- part numbers of archived products can be taken by new products
+ALTER TABLE products DROP CONSTRAINT products_part_number_key;
+CREATE UNIQUE INDEX products_part_number_key ON products (part_number) WHERE archived_at IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE low_stock_alerts DROP COLUMN IF EXISTS notify_claimed_at;
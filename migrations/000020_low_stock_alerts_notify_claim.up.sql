@@ -0,0 +1,3 @@
+-- alerts are notified after the evaluation is committed. An evaluator claims the unnotified alerts it notifies,
+-- so concurrent evaluators do not notify them twice; the claim of a crashed evaluator expires.
+ALTER TABLE low_stock_alerts ADD COLUMN notify_claimed_at TIMESTAMP;
@@ -0,0 +1,3 @@
+-- movements are filtered by instants, so they do not depend on the session time zone.
+-- The existing movements were written by sessions in UTC.
+ALTER TABLE stock_movements ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
@@ -0,0 +1 @@
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS lease_id, DROP COLUMN IF EXISTS renewed_at;
@@ -0,0 +1,6 @@
+-- the request holding an in-progress key renews it while it is handled, so the key is taken over
+-- only after the lease has not been renewed; lease_id identifies the holder, so a request which
+-- has lost the key can not renew, finish or release it
+ALTER TABLE idempotency_keys ADD COLUMN lease_id UUID, ADD COLUMN renewed_at TIMESTAMP;
+UPDATE idempotency_keys SET renewed_at = created_at;
+ALTER TABLE idempotency_keys ALTER COLUMN renewed_at SET NOT NULL, ALTER COLUMN renewed_at SET DEFAULT NOW();
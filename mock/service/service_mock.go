@@ -17,6 +17,42 @@ type ServiceMock struct {
 	mock.Mock
 }
 
+// AbortIdempotentRequest provides a mock function with given fields: ctx, key
+func (_m *ServiceMock) AbortIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AbortIdempotentRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotencyKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ArchiveProduct provides a mock function with given fields: ctx, partNumber
+func (_m *ServiceMock) ArchiveProduct(ctx context.Context, partNumber string) error {
+	ret := _m.Called(ctx, partNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ArchiveProduct")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, partNumber)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // AvailabilityProductsByWarehouseID provides a mock function with given fields: ctx, warehouseID
 func (_m *ServiceMock) AvailabilityProductsByWarehouseID(ctx context.Context, warehouseID int) ([]models.AvailabilityProducts, error) {
 	ret := _m.Called(ctx, warehouseID)
@@ -47,16 +83,46 @@ func (_m *ServiceMock) AvailabilityProductsByWarehouseID(ctx context.Context, wa
 	return r0, r1
 }
 
-// ConfirmOrCancelReservedProducts provides a mock function with given fields: ctx, status, req
-func (_m *ServiceMock) ConfirmOrCancelReservedProducts(ctx context.Context, status int, req models.CancelORConfirmProductsRequest) error {
+// BeginIdempotentRequest provides a mock function with given fields: ctx, key
+func (_m *ServiceMock) BeginIdempotentRequest(ctx context.Context, key models.IdempotencyKey) (*models.IdempotentResponse, error) {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginIdempotentRequest")
+	}
+
+	var r0 *models.IdempotentResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotencyKey) (*models.IdempotentResponse, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotencyKey) *models.IdempotentResponse); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.IdempotentResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.IdempotencyKey) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangeReservationStatus provides a mock function with given fields: ctx, status, req
+func (_m *ServiceMock) ChangeReservationStatus(ctx context.Context, status models.ReservationStatus, req models.CancelORConfirmProductsRequest) error {
 	ret := _m.Called(ctx, status, req)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ConfirmOrCancelReservedProducts")
+		panic("no return value specified for ChangeReservationStatus")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, models.CancelORConfirmProductsRequest) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, models.ReservationStatus, models.CancelORConfirmProductsRequest) error); ok {
 		r0 = rf(ctx, status, req)
 	} else {
 		r0 = ret.Error(0)
@@ -65,29 +131,451 @@ func (_m *ServiceMock) ConfirmOrCancelReservedProducts(ctx context.Context, stat
 	return r0
 }
 
-// Products provides a mock function with given fields: ctx
-func (_m *ServiceMock) Products(ctx context.Context) ([]models.Product, error) {
-	ret := _m.Called(ctx)
+// ChangeTransferStatus provides a mock function with given fields: ctx, transferID, status
+func (_m *ServiceMock) ChangeTransferStatus(ctx context.Context, transferID int, status models.TransferStatus) (models.Transfer, error) {
+	ret := _m.Called(ctx, transferID, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangeTransferStatus")
+	}
+
+	var r0 models.Transfer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransferStatus) (models.Transfer, error)); ok {
+		return rf(ctx, transferID, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.TransferStatus) models.Transfer); ok {
+		r0 = rf(ctx, transferID, status)
+	} else {
+		r0 = ret.Get(0).(models.Transfer)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.TransferStatus) error); ok {
+		r1 = rf(ctx, transferID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAdjustment provides a mock function with given fields: ctx, warehouseID, req
+func (_m *ServiceMock) CreateAdjustment(ctx context.Context, warehouseID int, req models.CreateAdjustmentRequest) (models.Adjustment, error) {
+	ret := _m.Called(ctx, warehouseID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAdjustment")
+	}
+
+	var r0 models.Adjustment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.CreateAdjustmentRequest) (models.Adjustment, error)); ok {
+		return rf(ctx, warehouseID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.CreateAdjustmentRequest) models.Adjustment); ok {
+		r0 = rf(ctx, warehouseID, req)
+	} else {
+		r0 = ret.Get(0).(models.Adjustment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.CreateAdjustmentRequest) error); ok {
+		r1 = rf(ctx, warehouseID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateProduct provides a mock function with given fields: ctx, req
+func (_m *ServiceMock) CreateProduct(ctx context.Context, req models.CreateProductRequest) (models.Product, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateProduct")
+	}
+
+	var r0 models.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateProductRequest) (models.Product, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateProductRequest) models.Product); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(models.Product)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CreateProductRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateReceipt provides a mock function with given fields: ctx, warehouseID, req
+func (_m *ServiceMock) CreateReceipt(ctx context.Context, warehouseID int, req models.CreateReceiptRequest) (models.Receipt, error) {
+	ret := _m.Called(ctx, warehouseID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateReceipt")
+	}
+
+	var r0 models.Receipt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.CreateReceiptRequest) (models.Receipt, error)); ok {
+		return rf(ctx, warehouseID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.CreateReceiptRequest) models.Receipt); ok {
+		r0 = rf(ctx, warehouseID, req)
+	} else {
+		r0 = ret.Get(0).(models.Receipt)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.CreateReceiptRequest) error); ok {
+		r1 = rf(ctx, warehouseID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateTransfer provides a mock function with given fields: ctx, req
+func (_m *ServiceMock) CreateTransfer(ctx context.Context, req models.CreateTransferRequest) (models.Transfer, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateTransfer")
+	}
+
+	var r0 models.Transfer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateTransferRequest) (models.Transfer, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateTransferRequest) models.Transfer); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(models.Transfer)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CreateTransferRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWarehouse provides a mock function with given fields: ctx, req
+func (_m *ServiceMock) CreateWarehouse(ctx context.Context, req models.CreateWarehouseRequest) (models.Warehouse, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWarehouse")
+	}
+
+	var r0 models.Warehouse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateWarehouseRequest) (models.Warehouse, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.CreateWarehouseRequest) models.Warehouse); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(models.Warehouse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.CreateWarehouseRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWarehouse provides a mock function with given fields: ctx, warehouseID
+func (_m *ServiceMock) DeleteWarehouse(ctx context.Context, warehouseID int) error {
+	ret := _m.Called(ctx, warehouseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWarehouse")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, warehouseID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FinishIdempotentRequest provides a mock function with given fields: ctx, key, resp
+func (_m *ServiceMock) FinishIdempotentRequest(ctx context.Context, key models.IdempotencyKey, resp models.IdempotentResponse) error {
+	ret := _m.Called(ctx, key, resp)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishIdempotentRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotencyKey, models.IdempotentResponse) error); ok {
+		r0 = rf(ctx, key, resp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// KeepIdempotentRequest provides a mock function with given fields: ctx, key
+func (_m *ServiceMock) KeepIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error {
+	ret := _m.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for KeepIdempotentRequest")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.IdempotencyKey) error); ok {
+		r0 = rf(ctx, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LowStockAlerts provides a mock function with given fields: ctx, req
+func (_m *ServiceMock) LowStockAlerts(ctx context.Context, req models.LowStockAlertsRequest) ([]models.LowStockAlert, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LowStockAlerts")
+	}
+
+	var r0 []models.LowStockAlert
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.LowStockAlertsRequest) ([]models.LowStockAlert, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.LowStockAlertsRequest) []models.LowStockAlert); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.LowStockAlert)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.LowStockAlertsRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NearestWarehouses provides a mock function with given fields: ctx, req
+func (_m *ServiceMock) NearestWarehouses(ctx context.Context, req models.NearestWarehousesRequest) ([]models.NearestWarehouse, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NearestWarehouses")
+	}
+
+	var r0 []models.NearestWarehouse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.NearestWarehousesRequest) ([]models.NearestWarehouse, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.NearestWarehousesRequest) []models.NearestWarehouse); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.NearestWarehouse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.NearestWarehousesRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Product provides a mock function with given fields: ctx, partNumber
+func (_m *ServiceMock) Product(ctx context.Context, partNumber string) (models.Product, error) {
+	ret := _m.Called(ctx, partNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Product")
+	}
+
+	var r0 models.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.Product, error)); ok {
+		return rf(ctx, partNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.Product); ok {
+		r0 = rf(ctx, partNumber)
+	} else {
+		r0 = ret.Get(0).(models.Product)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, partNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ProductAvailability provides a mock function with given fields: ctx, partNumber
+func (_m *ServiceMock) ProductAvailability(ctx context.Context, partNumber string) (models.ProductAvailability, error) {
+	ret := _m.Called(ctx, partNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ProductAvailability")
+	}
+
+	var r0 models.ProductAvailability
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.ProductAvailability, error)); ok {
+		return rf(ctx, partNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.ProductAvailability); ok {
+		r0 = rf(ctx, partNumber)
+	} else {
+		r0 = ret.Get(0).(models.ProductAvailability)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, partNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Products provides a mock function with given fields: ctx, req
+func (_m *ServiceMock) Products(ctx context.Context, req models.ProductsRequest) (models.ProductsPage, error) {
+	ret := _m.Called(ctx, req)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Products")
 	}
 
-	var r0 []models.Product
+	var r0 models.ProductsPage
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Product, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, models.ProductsRequest) (models.ProductsPage, error)); ok {
+		return rf(ctx, req)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) []models.Product); ok {
-		r0 = rf(ctx)
+	if rf, ok := ret.Get(0).(func(context.Context, models.ProductsRequest) models.ProductsPage); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(models.ProductsPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.ProductsRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RedeemReservationQuote provides a mock function with given fields: ctx, token
+func (_m *ServiceMock) RedeemReservationQuote(ctx context.Context, token string) (models.ReservationProductsResponse, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RedeemReservationQuote")
+	}
+
+	var r0 models.ReservationProductsResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (models.ReservationProductsResponse, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) models.ReservationProductsResponse); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(models.ReservationProductsResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReorderPoints provides a mock function with given fields: ctx, warehouseID
+func (_m *ServiceMock) ReorderPoints(ctx context.Context, warehouseID int) ([]models.ReorderPoint, error) {
+	ret := _m.Called(ctx, warehouseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReorderPoints")
+	}
+
+	var r0 []models.ReorderPoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]models.ReorderPoint, error)); ok {
+		return rf(ctx, warehouseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []models.ReorderPoint); ok {
+		r0 = rf(ctx, warehouseID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.Product)
+			r0 = ret.Get(0).([]models.ReorderPoint)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, warehouseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reservation provides a mock function with given fields: ctx, reservationID
+func (_m *ServiceMock) Reservation(ctx context.Context, reservationID uuid.UUID) (models.Reservation, error) {
+	ret := _m.Called(ctx, reservationID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Reservation")
+	}
+
+	var r0 models.Reservation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) (models.Reservation, error)); ok {
+		return rf(ctx, reservationID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) models.Reservation); ok {
+		r0 = rf(ctx, reservationID)
+	} else {
+		r0 = ret.Get(0).(models.Reservation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, reservationID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -96,24 +584,22 @@ func (_m *ServiceMock) Products(ctx context.Context) ([]models.Product, error) {
 }
 
 // ReservationProducts provides a mock function with given fields: ctx, data
-func (_m *ServiceMock) ReservationProducts(ctx context.Context, data models.ReservationProductsRequest) (uuid.UUID, error) {
+func (_m *ServiceMock) ReservationProducts(ctx context.Context, data models.ReservationProductsRequest) (models.ReservationProductsResponse, error) {
 	ret := _m.Called(ctx, data)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ReservationProducts")
 	}
 
-	var r0 uuid.UUID
+	var r0 models.ReservationProductsResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.ReservationProductsRequest) (uuid.UUID, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, models.ReservationProductsRequest) (models.ReservationProductsResponse, error)); ok {
 		return rf(ctx, data)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, models.ReservationProductsRequest) uuid.UUID); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, models.ReservationProductsRequest) models.ReservationProductsResponse); ok {
 		r0 = rf(ctx, data)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(uuid.UUID)
-		}
+		r0 = ret.Get(0).(models.ReservationProductsResponse)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, models.ReservationProductsRequest) error); ok {
@@ -122,5 +608,233 @@ func (_m *ServiceMock) ReservationProducts(ctx context.Context, data models.Rese
 		r1 = ret.Error(1)
 	}
 
+	return r0, r1
+}
+
+// ReservationQuote provides a mock function with given fields: ctx, req
+func (_m *ServiceMock) ReservationQuote(ctx context.Context, req models.ReservationProductsRequest) (models.ReservationQuote, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReservationQuote")
+	}
+
+	var r0 models.ReservationQuote
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.ReservationProductsRequest) (models.ReservationQuote, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.ReservationProductsRequest) models.ReservationQuote); ok {
+		r0 = rf(ctx, req)
+	} else {
+		r0 = ret.Get(0).(models.ReservationQuote)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.ReservationProductsRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetReorderPoints provides a mock function with given fields: ctx, warehouseID, req
+func (_m *ServiceMock) SetReorderPoints(ctx context.Context, warehouseID int, req models.SetReorderPointsRequest) ([]models.ReorderPoint, error) {
+	ret := _m.Called(ctx, warehouseID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetReorderPoints")
+	}
+
+	var r0 []models.ReorderPoint
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.SetReorderPointsRequest) ([]models.ReorderPoint, error)); ok {
+		return rf(ctx, warehouseID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.SetReorderPointsRequest) []models.ReorderPoint); ok {
+		r0 = rf(ctx, warehouseID, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.ReorderPoint)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.SetReorderPointsRequest) error); ok {
+		r1 = rf(ctx, warehouseID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StockMovements provides a mock function with given fields: ctx, warehouseID, req
+func (_m *ServiceMock) StockMovements(ctx context.Context, warehouseID int, req models.StockMovementsRequest) (models.StockMovementsPage, error) {
+	ret := _m.Called(ctx, warehouseID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StockMovements")
+	}
+
+	var r0 models.StockMovementsPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.StockMovementsRequest) (models.StockMovementsPage, error)); ok {
+		return rf(ctx, warehouseID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.StockMovementsRequest) models.StockMovementsPage); ok {
+		r0 = rf(ctx, warehouseID, req)
+	} else {
+		r0 = ret.Get(0).(models.StockMovementsPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.StockMovementsRequest) error); ok {
+		r1 = rf(ctx, warehouseID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Transfer provides a mock function with given fields: ctx, transferID
+func (_m *ServiceMock) Transfer(ctx context.Context, transferID int) (models.Transfer, error) {
+	ret := _m.Called(ctx, transferID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Transfer")
+	}
+
+	var r0 models.Transfer
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Transfer, error)); ok {
+		return rf(ctx, transferID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Transfer); ok {
+		r0 = rf(ctx, transferID)
+	} else {
+		r0 = ret.Get(0).(models.Transfer)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, transferID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProduct provides a mock function with given fields: ctx, partNumber, req
+func (_m *ServiceMock) UpdateProduct(ctx context.Context, partNumber string, req models.UpdateProductRequest) (models.Product, error) {
+	ret := _m.Called(ctx, partNumber, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProduct")
+	}
+
+	var r0 models.Product
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.UpdateProductRequest) (models.Product, error)); ok {
+		return rf(ctx, partNumber, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.UpdateProductRequest) models.Product); ok {
+		r0 = rf(ctx, partNumber, req)
+	} else {
+		r0 = ret.Get(0).(models.Product)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.UpdateProductRequest) error); ok {
+		r1 = rf(ctx, partNumber, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateWarehouse provides a mock function with given fields: ctx, warehouseID, req
+func (_m *ServiceMock) UpdateWarehouse(ctx context.Context, warehouseID int, req models.UpdateWarehouseRequest) (models.Warehouse, error) {
+	ret := _m.Called(ctx, warehouseID, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWarehouse")
+	}
+
+	var r0 models.Warehouse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.UpdateWarehouseRequest) (models.Warehouse, error)); ok {
+		return rf(ctx, warehouseID, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, models.UpdateWarehouseRequest) models.Warehouse); ok {
+		r0 = rf(ctx, warehouseID, req)
+	} else {
+		r0 = ret.Get(0).(models.Warehouse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, models.UpdateWarehouseRequest) error); ok {
+		r1 = rf(ctx, warehouseID, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Warehouse provides a mock function with given fields: ctx, warehouseID
+func (_m *ServiceMock) Warehouse(ctx context.Context, warehouseID int) (models.Warehouse, error) {
+	ret := _m.Called(ctx, warehouseID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Warehouse")
+	}
+
+	var r0 models.Warehouse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (models.Warehouse, error)); ok {
+		return rf(ctx, warehouseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) models.Warehouse); ok {
+		r0 = rf(ctx, warehouseID)
+	} else {
+		r0 = ret.Get(0).(models.Warehouse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, warehouseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Warehouses provides a mock function with given fields: ctx
+func (_m *ServiceMock) Warehouses(ctx context.Context) ([]models.Warehouse, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Warehouses")
+	}
+
+	var r0 []models.Warehouse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]models.Warehouse, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []models.Warehouse); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Warehouse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
 	return r0, r1
 }
\ No newline at end of file
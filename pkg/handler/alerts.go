@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+func (h *Handler) reorderPoints(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	points, err := h.services.ReorderPoints(r.Context(), warehouseID)
+	if err != nil {
+		log.Errorf("error to get reorder points: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, points)
+}
+
+func (h *Handler) setReorderPoints(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	var req models.SetReorderPointsRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err = h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	points, err := h.services.SetReorderPoints(r.Context(), warehouseID, req)
+	if err != nil {
+		log.Errorf("error to set reorder points: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, points)
+}
+
+func (h *Handler) lowStockAlerts(w http.ResponseWriter, r *http.Request) {
+	var req models.LowStockAlertsRequest
+	if val := r.URL.Query().Get("warehouse_id"); val != "" {
+		warehouseID, err := strconv.Atoi(val)
+		if err != nil {
+			log.Errorf("error to convert warehouse_id: %v", err)
+			writeError(w, paramError("warehouse_id", "number", err))
+			return
+		}
+		req.WarehouseID = &warehouseID
+	}
+
+	alerts, err := h.services.LowStockAlerts(r.Context(), req)
+	if err != nil {
+		log.Errorf("error to get low stock alerts: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, alerts)
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/go-playground/validator/v10"
+)
+
+var errorStatusCodes = map[models.ErrorCode]int{
+	models.CodeNotFound:             http.StatusNotFound,
+	models.CodeInsufficientStock:    http.StatusConflict,
+	models.CodeValidationFailed:     http.StatusBadRequest,
+	models.CodeConflict:             http.StatusConflict,
+	models.CodeUnknownPartNumber:    http.StatusUnprocessableEntity,
+	models.CodeIdempotencyKeyReused: http.StatusUnprocessableEntity,
+	models.CodeQuoteStale:           http.StatusConflict,
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    models.ErrorCode    `json:"code"`
+	Message string              `json:"message"`
+	Fields  []models.FieldError `json:"fields,omitempty"`
+	Details interface{}         `json:"details,omitempty"`
+}
+
+// publicError is an error with a message which is safe to report to API clients.
+type publicError interface {
+	PublicMessage() string
+}
+
+// writeError writes err as a JSON error envelope. Errors which do not wrap a *models.Error
+// are reported as internal. The message of the wrapped chain may contain details of the storage,
+// so only the message of the sentinel, or of a public error in the chain, is reported.
+// Handlers log the whole chain.
+func writeError(w http.ResponseWriter, err error) {
+	statusCode, body := http.StatusInternalServerError, errorBody{
+		Code:    models.CodeInternal,
+		Message: http.StatusText(http.StatusInternalServerError),
+	}
+
+	var apiErr *models.Error
+	if errors.As(err, &apiErr) {
+		statusCode, body.Code, body.Message = errorStatusCodes[apiErr.Code], apiErr.Code, apiErr.Message
+
+		var public publicError
+		if errors.As(err, &public) {
+			body.Message = public.PublicMessage()
+		}
+	}
+
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		body.Fields = validationErr.Fields
+	}
+
+	var conflictErr *models.StatusConflictError
+	if errors.As(err, &conflictErr) {
+		body.Details = conflictErr.Lines
+	}
+
+	var allocationErr *models.AllocationError
+	if errors.As(err, &allocationErr) {
+		body.Details = allocationErr.Items
+	}
+
+	writeJSON(w, statusCode, errorResponse{Error: body})
+}
+
+func newValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	_ = validate.RegisterValidation("requestable_status", func(fl validator.FieldLevel) bool {
+		status, ok := fl.Field().Interface().(models.ReservationStatus)
+		return ok && status.Requestable()
+	})
+	return validate
+}
+
+// validationError converts errors of the validator to *models.ValidationError,
+// naming fields as they are named in the request body.
+func validationError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return fmt.Errorf("%w: %v", models.ErrValidation, err)
+	}
+
+	fields := make([]models.FieldError, len(validationErrs))
+	for i, e := range validationErrs {
+		// the namespace starts with the name of the request struct
+		_, field, _ := strings.Cut(e.Namespace(), ".")
+		fields[i] = models.FieldError{
+			Field:   field,
+			Rule:    e.Tag(),
+			Param:   e.Param(),
+			Message: fieldErrorMessage(field, e),
+		}
+	}
+	return &models.ValidationError{Fields: fields}
+}
+
+func fieldErrorMessage(field string, e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", field, e.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", field, e.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", field, e.Param())
+	case "requestable_status":
+		return fmt.Sprintf("%s can not be requested to be %v", field, e.Value())
+	case "latitude", "longitude":
+		return fmt.Sprintf("%s must be a valid %s", field, e.Tag())
+	default:
+		return fmt.Sprintf("%s failed on the %s rule", field, e.Tag())
+	}
+}
+
+// decodeError reports a request body which can not be decoded as a validation error.
+func decodeError(err error) error {
+	return &models.ValidationError{Fields: []models.FieldError{{
+		Field:   "body",
+		Rule:    "json",
+		Message: fmt.Sprintf("invalid request body: %v", err),
+	}}}
+}
+
+// paramError reports a path or query parameter which can not be parsed as a validation error.
+func paramError(field, rule string, err error) error {
+	return &models.ValidationError{Fields: []models.FieldError{{
+		Field:   field,
+		Rule:    rule,
+		Message: fmt.Sprintf("invalid %s: %v", field, err),
+	}}}
+}
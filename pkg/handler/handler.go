@@ -15,13 +15,47 @@ import (
 
 //go:generate mockery --name=service --output=../../mock/service --outpkg=service_mock --filename=service_mock.go
 type service interface {
-	Products(ctx context.Context) ([]models.Product, error)
+	Products(ctx context.Context, req models.ProductsRequest) (models.ProductsPage, error)
 	AvailabilityProductsByWarehouseID(ctx context.Context, warehouseID int) ([]models.AvailabilityProducts, error)
+	ProductAvailability(ctx context.Context, partNumber string) (models.ProductAvailability, error)
 
-	ReservationProducts(ctx context.Context, data models.ReservationProductsRequest) (uuid.UUID, error)
-	ConfirmOrCancelReservedProducts(ctx context.Context, status int, req models.CancelORConfirmProductsRequest) error
+	Reservation(ctx context.Context, reservationID uuid.UUID) (models.Reservation, error)
+	ReservationProducts(ctx context.Context, data models.ReservationProductsRequest) (models.ReservationProductsResponse, error)
+	ReservationQuote(ctx context.Context, req models.ReservationProductsRequest) (models.ReservationQuote, error)
+	RedeemReservationQuote(ctx context.Context, token string) (models.ReservationProductsResponse, error)
+	ChangeReservationStatus(ctx context.Context, status models.ReservationStatus, req models.CancelORConfirmProductsRequest) error
+
+	Product(ctx context.Context, partNumber string) (models.Product, error)
+	CreateProduct(ctx context.Context, req models.CreateProductRequest) (models.Product, error)
+	UpdateProduct(ctx context.Context, partNumber string, req models.UpdateProductRequest) (models.Product, error)
+	ArchiveProduct(ctx context.Context, partNumber string) error
+
+	Warehouses(ctx context.Context) ([]models.Warehouse, error)
+	Warehouse(ctx context.Context, warehouseID int) (models.Warehouse, error)
+	NearestWarehouses(ctx context.Context, req models.NearestWarehousesRequest) ([]models.NearestWarehouse, error)
+	CreateWarehouse(ctx context.Context, req models.CreateWarehouseRequest) (models.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouseID int, req models.UpdateWarehouseRequest) (models.Warehouse, error)
+	DeleteWarehouse(ctx context.Context, warehouseID int) error
+	StockMovements(ctx context.Context, warehouseID int, req models.StockMovementsRequest) (models.StockMovementsPage, error)
+	CreateReceipt(ctx context.Context, warehouseID int, req models.CreateReceiptRequest) (models.Receipt, error)
+	CreateAdjustment(ctx context.Context, warehouseID int, req models.CreateAdjustmentRequest) (models.Adjustment, error)
+	ReorderPoints(ctx context.Context, warehouseID int) ([]models.ReorderPoint, error)
+	SetReorderPoints(ctx context.Context, warehouseID int, req models.SetReorderPointsRequest) ([]models.ReorderPoint, error)
+	LowStockAlerts(ctx context.Context, req models.LowStockAlertsRequest) ([]models.LowStockAlert, error)
+
+	CreateTransfer(ctx context.Context, req models.CreateTransferRequest) (models.Transfer, error)
+	Transfer(ctx context.Context, transferID int) (models.Transfer, error)
+	ChangeTransferStatus(ctx context.Context, transferID int, status models.TransferStatus) (models.Transfer, error)
+
+	BeginIdempotentRequest(ctx context.Context, key models.IdempotencyKey) (*models.IdempotentResponse, error)
+	KeepIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error
+	FinishIdempotentRequest(ctx context.Context, key models.IdempotencyKey, resp models.IdempotentResponse) error
+	AbortIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error
 }
 
+// defaultProductsLimit is the size of a page of products if the limit is not requested.
+const defaultProductsLimit = 50
+
 type Handler struct {
 	services service
 	validate *validator.Validate
@@ -30,7 +64,7 @@ type Handler struct {
 func NewHandler(service service) *Handler {
 	return &Handler{
 		services: service,
-		validate: validator.New(),
+		validate: newValidator(),
 	}
 }
 
@@ -39,18 +73,93 @@ func (h *Handler) NewRoutes() *chi.Mux {
 
 	mux.Get("/products", h.products)
 	mux.Get("/products/availability", h.availabilityProduct)
-	mux.Post("/reservation-products", h.reservationProducts)
-	mux.Delete("/reservation-products", h.cancelReservationProducts)
-	mux.Post("/confirm-reservation", h.confirmReservationProducts)
+	mux.Post("/products", h.createProduct)
+	mux.Get("/products/{part_number}", h.product)
+	mux.Patch("/products/{part_number}", h.updateProduct)
+	mux.Delete("/products/{part_number}", h.archiveProduct)
+	mux.Get("/products/{part_number}/availability", h.productAvailability)
+	mux.Post("/reservation-products", h.idempotent(h.reservationProducts))
+	mux.Post("/reservation-quotes", h.reservationQuote)
+	mux.Post("/reservation-quotes/redeem", h.idempotent(h.redeemReservationQuote))
+	mux.Delete("/reservation-products", h.idempotent(h.cancelReservationProducts))
+	mux.Post("/confirm-reservation", h.idempotent(h.confirmReservationProducts))
+	mux.Get("/reservations/{id}", h.reservation)
+	mux.Patch("/reservations/{id}", h.idempotent(h.changeReservationStatus))
+
+	mux.Get("/warehouses", h.warehouses)
+	mux.Post("/warehouses", h.createWarehouse)
+	mux.Get("/warehouses/nearest", h.nearestWarehouses)
+	mux.Get("/warehouses/{id}", h.warehouse)
+	mux.Patch("/warehouses/{id}", h.updateWarehouse)
+	mux.Delete("/warehouses/{id}", h.deleteWarehouse)
+	mux.Get("/warehouses/{id}/movements", h.stockMovements)
+	mux.Post("/warehouses/{id}/receipts", h.idempotent(h.createReceipt))
+	mux.Post("/warehouses/{id}/adjustments", h.idempotent(h.createAdjustment))
+	mux.Get("/warehouses/{id}/reorder-points", h.reorderPoints)
+	mux.Put("/warehouses/{id}/reorder-points", h.setReorderPoints)
+
+	mux.Get("/alerts/low-stock", h.lowStockAlerts)
+
+	mux.Post("/transfers", h.idempotent(h.createTransfer))
+	mux.Get("/transfers/{id}", h.transfer)
+	mux.Patch("/transfers/{id}", h.idempotent(h.changeTransferStatus))
 
 	return mux
 }
 
 func (h *Handler) products(w http.ResponseWriter, r *http.Request) {
-	products, err := h.services.Products(r.Context())
+	query := r.URL.Query()
+	req := models.ProductsRequest{
+		Limit:      defaultProductsLimit,
+		Cursor:     query.Get("cursor"),
+		PartNumber: query.Get("part_number"),
+		Title:      query.Get("title"),
+		Sort:       query.Get("sort"),
+	}
+
+	// params are parsed in a fixed order, so the first invalid one is always reported
+	for _, param := range []struct {
+		name string
+		dst  **int
+	}{
+		{"min_width", &req.MinWidth},
+		{"max_width", &req.MaxWidth},
+		{"min_height", &req.MinHeight},
+		{"max_height", &req.MaxHeight},
+		{"min_depth", &req.MinDepth},
+		{"max_depth", &req.MaxDepth},
+	} {
+		if val := query.Get(param.name); val != "" {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				log.Errorf("error to convert %s: %v", param.name, err)
+				writeError(w, paramError(param.name, "number", err))
+				return
+			}
+			*param.dst = &n
+		}
+	}
+
+	if val := query.Get("limit"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			log.Errorf("error to convert limit: %v", err)
+			writeError(w, paramError("limit", "number", err))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	products, err := h.services.Products(r.Context(), req)
 	if err != nil {
 		log.Errorf("error to get products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -59,28 +168,31 @@ func (h *Handler) products(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(products); err != nil {
 		log.Errorf("error to encode products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
 func (h *Handler) availabilityProduct(w http.ResponseWriter, r *http.Request) {
 	queryVal := r.URL.Query().Get("warehouse_id")
 	if queryVal == "" {
-		http.Error(w, "warehouse_id is required", http.StatusBadRequest)
+		writeError(w, &models.ValidationError{Fields: []models.FieldError{{
+			Field:   "warehouse_id",
+			Rule:    "required",
+			Message: "warehouse_id is required",
+		}}})
 		return
 	}
 
 	warehouseID, err := strconv.Atoi(queryVal)
 	if err != nil {
 		log.Errorf("error to convert warehouse_id: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, paramError("warehouse_id", "number", err))
 		return
 	}
 
 	reservedProducts, err := h.services.AvailabilityProductsByWarehouseID(r.Context(), warehouseID)
 	if err != nil {
 		log.Errorf("error to get reserved products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -88,7 +200,6 @@ func (h *Handler) availabilityProduct(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(reservedProducts); err != nil {
 		log.Errorf("error to encode products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
@@ -98,30 +209,27 @@ func (h *Handler) reservationProducts(w http.ResponseWriter, r *http.Request) {
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		log.Errorf("error to decode request: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, decodeError(err))
 		return
 	}
 
 	if err = h.validate.Struct(req); err != nil {
 		log.Errorf("validation error: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, validationError(err))
 		return
 	}
 
-	reservationID, err := h.services.ReservationProducts(r.Context(), req)
+	reservation, err := h.services.ReservationProducts(r.Context(), req)
 	if err != nil {
 		log.Errorf("error to reservation products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(
-		map[string]uuid.UUID{"reservation_id": reservationID},
-	); err != nil {
+	if err := json.NewEncoder(w).Encode(reservation); err != nil {
 		log.Errorf("error to encode products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
@@ -131,19 +239,19 @@ func (h *Handler) cancelReservationProducts(w http.ResponseWriter, r *http.Reque
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		log.Errorf("error to decode request: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, decodeError(err))
 		return
 	}
 
 	if err = h.validate.Struct(req); err != nil {
 		log.Errorf("validation error: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, validationError(err))
 		return
 	}
 
-	if err = h.services.ConfirmOrCancelReservedProducts(r.Context(), 2, req); err != nil {
+	if err = h.services.ChangeReservationStatus(r.Context(), models.StatusCancelled, req); err != nil {
 		log.Errorf("error to cancel reserved products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -156,21 +264,84 @@ func (h *Handler) confirmReservationProducts(w http.ResponseWriter, r *http.Requ
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		log.Errorf("error to decode request: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, decodeError(err))
 		return
 	}
 
 	if err = h.validate.Struct(req); err != nil {
 		log.Errorf("validation error: %v", err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, validationError(err))
 		return
 	}
 
-	if err = h.services.ConfirmOrCancelReservedProducts(r.Context(), 2, req); err != nil {
+	if err = h.services.ChangeReservationStatus(r.Context(), models.StatusConfirmed, req); err != nil {
 		log.Errorf("error to confirm reserved products: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) reservation(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to parse reservation id: %v", err)
+		writeError(w, paramError("id", "uuid", err))
+		return
+	}
+
+	reservation, err := h.services.Reservation(r.Context(), reservationID)
+	if err != nil {
+		log.Errorf("error to get reservation: %v", err)
+		writeError(w, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(reservation); err != nil {
+		log.Errorf("error to encode reservation: %v", err)
+	}
+}
+
+func (h *Handler) changeReservationStatus(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to parse reservation id: %v", err)
+		writeError(w, paramError("id", "uuid", err))
+		return
+	}
+
+	var req models.ReservationStatusRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err = h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	if err = h.services.ChangeReservationStatus(r.Context(), *req.Status, models.CancelORConfirmProductsRequest{
+		ReservationID: reservationID,
+		PartNumbers:   req.PartNumbers,
+	}); err != nil {
+		log.Errorf("error to change reservation status: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("error to encode response: %v", err)
+	}
 }
@@ -2,10 +2,13 @@ package handler_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	mockservice "github.com/Hymiside/lamoda-api/mock/service"
 	"github.com/Hymiside/lamoda-api/pkg/handler"
@@ -19,7 +22,7 @@ func TestHandler_products(t *testing.T) {
 	svc := new(mockservice.ServiceMock)
 	h := handler.NewHandler(svc)
 
-	svc.On("Products", mock.Anything).Return([]models.Product{}, nil)
+	svc.On("Products", mock.Anything, models.ProductsRequest{Limit: 50}).Return(models.ProductsPage{Products: []models.Product{}}, nil)
 
 	req, err := http.NewRequest("GET", "/products", nil)
 	assert.NoError(t, err)
@@ -31,12 +34,84 @@ func TestHandler_products(t *testing.T) {
 	svc.AssertExpectations(t)
 }
 
+func TestHandler_productsFiltered(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	minWidth, maxDepth := 5, 20
+	svc.On("Products", mock.Anything, models.ProductsRequest{
+		Limit:      2,
+		Cursor:     "eyJzb3J0IjoiLXRpdGxlIiwidmFsdWUiOiJQcm9kdWN0IDciLCJpZCI6M30",
+		PartNumber: "P13",
+		Title:      "product",
+		MinWidth:   &minWidth,
+		MaxDepth:   &maxDepth,
+		Sort:       "-title",
+	}).Return(models.ProductsPage{
+		Products: []models.Product{
+			{ID: 2, PartNumber: "P13579", Title: "Product 6", Width: 10, Height: 23, Depth: 15},
+		},
+		NextCursor: "eyJzb3J0IjoiLXRpdGxlIiwidmFsdWUiOiJQcm9kdWN0IDYiLCJpZCI6Mn0",
+	}, nil)
+
+	req, err := http.NewRequest("GET", "/products?limit=2&part_number=P13&title=product&min_width=5&max_depth=20&sort=-title"+
+		"&cursor=eyJzb3J0IjoiLXRpdGxlIiwidmFsdWUiOiJQcm9kdWN0IDciLCJpZCI6M30", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"products": [{"id": 2, "part_number": "P13579", "title": "Product 6", "width": 10, "height": 23, "depth": 15}],
+		"next_cursor": "eyJzb3J0IjoiLXRpdGxlIiwidmFsdWUiOiJQcm9kdWN0IDYiLCJpZCI6Mn0"
+	}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_productsInvalidDimensions(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("GET", "/products?max_depth=deep&min_width=wide", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"min_width","rule":"number"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_productsInvalidSort(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("GET", "/products?sort=price", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"sort","rule":"oneof"`)
+	svc.AssertExpectations(t)
+}
+
 func TestHandler_availabilityProduct(t *testing.T) {
 	svc := new(mockservice.ServiceMock)
 	h := handler.NewHandler(svc)
 
 	warehouseID := 1
-	svc.On("AvailabilityProductsByWarehouseID", mock.Anything, warehouseID).Return([]models.AvailabilityProducts{}, nil)
+	svc.On("AvailabilityProductsByWarehouseID", mock.Anything, warehouseID).Return([]models.AvailabilityProducts{{
+		Product:        models.Product{ID: 2, PartNumber: "P13579", Title: "Product 6"},
+		WarehouseAvail: true,
+		OnHand:         23,
+		Reserved:       3,
+		Available:      20,
+		InTransit:      5,
+	}}, nil)
 
 	req, err := http.NewRequest("GET", "/products/availability?warehouse_id=1", nil)
 	assert.NoError(t, err)
@@ -45,6 +120,14 @@ func TestHandler_availabilityProduct(t *testing.T) {
 	h.NewRoutes().ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{
+		"Product": {"id": 2, "part_number": "P13579", "title": "Product 6"},
+		"WarehouseAvail": true,
+		"OnHand": 23,
+		"Reserved": 3,
+		"Available": 20,
+		"InTransit": 5
+	}]`, rr.Body.String())
 	svc.AssertExpectations(t)
 }
 
@@ -53,12 +136,28 @@ func TestHandler_reservationProducts(t *testing.T) {
 	h := handler.NewHandler(svc)
 
 	reservationRequest := models.ReservationProductsRequest{
-		PartNumbers: []string{"P13579", "P97431", "P13279"},
-		Latitude:    21.213,
-		Longitude:   32.23,
+		Items: []models.ReservationItem{
+			{PartNumber: "P13579", Quantity: 3},
+			{PartNumber: "P97431", Quantity: 1},
+			{PartNumber: "P13279", Quantity: 1},
+		},
+		Latitude:  21.213,
+		Longitude: 32.23,
 	}
-	reservationID := uuid.New()
-	svc.On("ReservationProducts", mock.Anything, reservationRequest).Return(reservationID, nil)
+	reservation := models.ReservationProductsResponse{
+		ReservationID: uuid.New(),
+		Items: []models.ReservedItem{
+			{
+				PartNumber: "P13579",
+				Quantity:   3,
+				Warehouses: []models.ReservedWarehouse{
+					{WarehouseID: 1, Quantity: 2, Distance: 120.5},
+					{WarehouseID: 2, Quantity: 1, Distance: 340.1},
+				},
+			},
+		},
+	}
+	svc.On("ReservationProducts", mock.Anything, reservationRequest).Return(reservation, nil)
 
 	requestBody, _ := json.Marshal(reservationRequest)
 	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBuffer(requestBody))
@@ -68,6 +167,10 @@ func TestHandler_reservationProducts(t *testing.T) {
 	h.NewRoutes().ServeHTTP(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var resp models.ReservationProductsResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, reservation, resp)
 	svc.AssertExpectations(t)
 }
 
@@ -79,7 +182,7 @@ func TestHandler_cancelReservationProducts(t *testing.T) {
 		ReservationID: uuid.New(),
 		PartNumbers:   []string{"P13579"},
 	}
-	svc.On("ConfirmOrCancelReservedProducts", mock.Anything, 2, cancelRequest).Return(nil)
+	svc.On("ChangeReservationStatus", mock.Anything, models.StatusCancelled, cancelRequest).Return(nil)
 
 	requestBody, _ := json.Marshal(cancelRequest)
 	req, err := http.NewRequest("DELETE", "/reservation-products", bytes.NewBuffer(requestBody))
@@ -100,7 +203,7 @@ func TestHandler_confirmReservationProducts(t *testing.T) {
 		ReservationID: uuid.New(),
 		PartNumbers:   []string{"P13579"},
 	}
-	svc.On("ConfirmOrCancelReservedProducts", mock.Anything, 2, confirmRequest).Return(nil)
+	svc.On("ChangeReservationStatus", mock.Anything, models.StatusConfirmed, confirmRequest).Return(nil)
 
 	requestBody, _ := json.Marshal(confirmRequest)
 	req, err := http.NewRequest("POST", "/confirm-reservation", bytes.NewBuffer(requestBody))
@@ -112,3 +215,971 @@ func TestHandler_confirmReservationProducts(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rr.Code)
 	svc.AssertExpectations(t)
 }
+
+func TestHandler_reservation(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	reservation := models.Reservation{
+		ReservationID: uuid.New(),
+		Status:        "reserved",
+		Lines: []models.ReservationLine{
+			{PartNumber: "P13579", Title: "Product 6", WarehouseID: 1, Warehouse: "Warehouse G", Quantity: 3, Status: models.StatusReserved},
+		},
+	}
+	svc.On("Reservation", mock.Anything, reservation.ReservationID).Return(reservation, nil)
+
+	req, err := http.NewRequest("GET", "/reservations/"+reservation.ReservationID.String(), nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationNotFound(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	reservationID := uuid.New()
+	svc.On("Reservation", mock.Anything, reservationID).Return(models.Reservation{}, fmt.Errorf("reservation: %w", models.ErrNotFound))
+
+	req, err := http.NewRequest("GET", "/reservations/"+reservationID.String(), nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_changeReservationStatus(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	reservationID := uuid.New()
+	svc.On("ChangeReservationStatus", mock.Anything, models.StatusShipped, models.CancelORConfirmProductsRequest{
+		ReservationID: reservationID,
+		PartNumbers:   []string{"P13579"},
+	}).Return(nil)
+
+	req, err := http.NewRequest("PATCH", "/reservations/"+reservationID.String(), bytes.NewBufferString(`{"status": "shipped", "part_numbers": ["P13579"]}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_changeReservationStatusValidation(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		body string
+		rule string
+	}{
+		{"missing", `{}`, "required"},
+		{"reserved", `{"status": "reserved"}`, "requestable_status"},
+		{"expired", `{"status": "expired"}`, "requestable_status"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(mockservice.ServiceMock)
+			h := handler.NewHandler(svc)
+
+			req, err := http.NewRequest("PATCH", "/reservations/"+uuid.New().String(), bytes.NewBufferString(tt.body))
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			h.NewRoutes().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), `"field":"status","rule":"`+tt.rule+`"`)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandler_changeReservationStatusConflict(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	reservationID := uuid.New()
+	conflict := &models.StatusConflictError{
+		Status: models.StatusConfirmed,
+		Lines:  []models.ReservationLine{{PartNumber: "P13579", WarehouseID: 1, Status: models.StatusCancelled}},
+	}
+	svc.On("ChangeReservationStatus", mock.Anything, models.StatusConfirmed, models.CancelORConfirmProductsRequest{
+		ReservationID: reservationID,
+	}).Return(conflict)
+
+	req, err := http.NewRequest("PATCH", "/reservations/"+reservationID.String(), bytes.NewBufferString(`{"status": "confirmed"}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "P13579 in warehouse 1 is cancelled")
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsIdempotent(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	reservationRequest := models.ReservationProductsRequest{
+		Items:     []models.ReservationItem{{PartNumber: "P13579", Quantity: 1}},
+		Latitude:  21.213,
+		Longitude: 32.23,
+	}
+	reservation := models.ReservationProductsResponse{ReservationID: uuid.New()}
+	svc.On("BeginIdempotentRequest", mock.Anything, mock.MatchedBy(func(key models.IdempotencyKey) bool {
+		return key.Key == "key-1" && key.Scope == "POST /reservation-products" && key.LeaseID != uuid.Nil
+	})).Return(nil, nil)
+	svc.On("KeepIdempotentRequest", mock.Anything, mock.Anything).Return(nil)
+	svc.On("ReservationProducts", mock.Anything, reservationRequest).Return(reservation, nil)
+	svc.On("FinishIdempotentRequest", mock.Anything, mock.Anything, mock.MatchedBy(func(resp models.IdempotentResponse) bool {
+		return resp.StatusCode == http.StatusOK && bytes.Contains(resp.Body, []byte(reservation.ReservationID.String()))
+	})).Return(nil)
+
+	requestBody, _ := json.Marshal(reservationRequest)
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBuffer(requestBody))
+	assert.NoError(t, err)
+	req.Header.Set("Idempotency-Key", "key-1")
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsIdempotentServerError(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("BeginIdempotentRequest", mock.Anything, mock.Anything).Return(nil, nil)
+	svc.On("KeepIdempotentRequest", mock.Anything, mock.Anything).Return(nil)
+	svc.On("ReservationProducts", mock.Anything, mock.Anything).Return(models.ReservationProductsResponse{}, fmt.Errorf("connection refused"))
+	svc.On("AbortIdempotentRequest", mock.Anything, mock.MatchedBy(func(key models.IdempotencyKey) bool {
+		return key.Key == "key-1"
+	})).Return(nil)
+
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBufferString(
+		`{"items": [{"part_number": "P13579", "quantity": 1}], "latitude": 21.213, "longitude": 32.23}`,
+	))
+	assert.NoError(t, err)
+	req.Header.Set("Idempotency-Key", "key-1")
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	svc.AssertNotCalled(t, "FinishIdempotentRequest", mock.Anything, mock.Anything, mock.Anything)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsIdempotencyKeyTakenOver(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("BeginIdempotentRequest", mock.Anything, mock.Anything).Return(nil, nil)
+	svc.On("KeepIdempotentRequest", mock.Anything, mock.Anything).
+		Return(models.ErrConflict.WithDetail(`idempotency key "key-1" has been taken over`))
+	// the request is cancelled, so it is not handled along with the request which has taken over the key
+	svc.On("ReservationProducts", mock.Anything, mock.Anything).
+		Return(func(ctx context.Context, _ models.ReservationProductsRequest) (models.ReservationProductsResponse, error) {
+			<-ctx.Done()
+			return models.ReservationProductsResponse{}, ctx.Err()
+		})
+	svc.On("AbortIdempotentRequest", mock.Anything, mock.Anything).Return(nil)
+
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBufferString(
+		`{"items": [{"part_number": "P13579", "quantity": 1}], "latitude": 21.213, "longitude": 32.23}`,
+	))
+	assert.NoError(t, err)
+	req.Header.Set("Idempotency-Key", "key-1")
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	svc.AssertNotCalled(t, "FinishIdempotentRequest", mock.Anything, mock.Anything, mock.Anything)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsIdempotentReplay(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	stored := &models.IdempotentResponse{
+		StatusCode:  http.StatusOK,
+		ContentType: "application/json",
+		Body:        []byte(`{"reservation_id":"00000000-0000-0000-0000-000000000000"}`),
+	}
+	svc.On("BeginIdempotentRequest", mock.Anything, mock.Anything).Return(stored, nil)
+
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBufferString(`{}`))
+	assert.NoError(t, err)
+	req.Header.Set("Idempotency-Key", "key-1")
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, string(stored.Body), rr.Body.String())
+	svc.AssertNotCalled(t, "ReservationProducts", mock.Anything, mock.Anything)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsIdempotencyKeyReused(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("BeginIdempotentRequest", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("key-1: %w", models.ErrIdempotencyKeyReused))
+
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBufferString(`{}`))
+	assert.NoError(t, err)
+	req.Header.Set("Idempotency-Key", "key-1")
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsValidationFailed(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBufferString(
+		`{"items": [{"part_number": "P13579", "quantity": 0}], "latitude": 21.213, "longitude": 32.23}`,
+	))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"error": {
+		"code": "validation_failed",
+		"message": "validation failed: items[0].quantity is required",
+		"fields": [{"field": "items[0].quantity", "rule": "required", "message": "items[0].quantity is required"}]
+	}}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsInvalidBody(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBufferString(`{"items": `))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"validation_failed"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_productsInternalError(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("Products", mock.Anything, mock.Anything).Return(models.ProductsPage{}, fmt.Errorf("query error: pq: relation \"products\" does not exist"))
+
+	req, err := http.NewRequest("GET", "/products", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.JSONEq(t, `{"error": {"code": "internal", "message": "Internal Server Error"}}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsAllocationFailed(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	reservationRequest := models.ReservationProductsRequest{
+		Items: []models.ReservationItem{
+			{PartNumber: "P13579", Quantity: 1},
+			{PartNumber: "P00000", Quantity: 1},
+		},
+		Latitude:  21.213,
+		Longitude: 32.23,
+	}
+	svc.On("ReservationProducts", mock.Anything, reservationRequest).Return(models.ReservationProductsResponse{}, &models.AllocationError{
+		Items: []models.ReservedItem{
+			{PartNumber: "P13579", Quantity: 1, Status: models.ItemUnavailable},
+			{PartNumber: "P00000", Quantity: 1, Status: models.ItemUnknown},
+		},
+	})
+
+	requestBody, _ := json.Marshal(reservationRequest)
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBuffer(requestBody))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, `{"error": {
+		"code": "unknown_part_number",
+		"message": "can not reserve products: P13579 is unavailable, P00000 is unknown",
+		"details": [
+			{"part_number": "P13579", "quantity": 1, "status": "unavailable"},
+			{"part_number": "P00000", "quantity": 1, "status": "unknown"}
+		]
+	}}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createWarehouse(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	lat, long := 58.0105, 56.2502
+	createRequest := models.CreateWarehouseRequest{Title: "Warehouse P", Available: true, Latitude: &lat, Longitude: &long}
+	svc.On("CreateWarehouse", mock.Anything, createRequest).Return(models.Warehouse{
+		ID: 9, Title: "Warehouse P", Available: true, Latitude: lat, Longitude: long,
+	}, nil)
+
+	requestBody, _ := json.Marshal(createRequest)
+	req, err := http.NewRequest("POST", "/warehouses", bytes.NewBuffer(requestBody))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.JSONEq(t, `{"id": 9, "title": "Warehouse P", "available": true, "lat": 58.0105, "long": 56.2502}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createWarehouseValidationFailed(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("POST", "/warehouses", bytes.NewBufferString(`{"title": "Warehouse P", "lat": 91, "long": 56.2502}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"lat","rule":"latitude"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_deleteWarehouseConflict(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("DeleteWarehouse", mock.Anything, 1).Return(fmt.Errorf("warehouse 1 has 2 active reserved products: %w", models.ErrConflict))
+
+	req, err := http.NewRequest("DELETE", "/warehouses/1", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"conflict"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createProductConflict(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	createRequest := models.CreateProductRequest{PartNumber: "P13579", Title: "Product 6", Width: 10, Height: 23, Depth: 15}
+	svc.On("CreateProduct", mock.Anything, createRequest).Return(models.Product{}, fmt.Errorf("error to create product: %w", models.ErrConflict.WithDetail("product P13579 already exists")))
+
+	requestBody, _ := json.Marshal(createRequest)
+	req, err := http.NewRequest("POST", "/products", bytes.NewBuffer(requestBody))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.JSONEq(t, `{"error": {"code": "conflict", "message": "product P13579 already exists: conflict"}}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationProductsInsufficientStockHidesDetails(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("ReservationProducts", mock.Anything, mock.Anything).Return(models.ReservationProductsResponse{},
+		fmt.Errorf("error to set products to reserved: not enough quantity of product 2 in warehouse 4: %w", models.ErrInsufficientStock))
+
+	req, err := http.NewRequest("POST", "/reservation-products", bytes.NewBufferString(
+		`{"items": [{"part_number": "P13579", "quantity": 1}], "latitude": 21.213, "longitude": 32.23}`,
+	))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.JSONEq(t, `{"error": {"code": "insufficient_stock", "message": "insufficient stock"}}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createProductNegativeDimension(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("POST", "/products", bytes.NewBufferString(`{"part_number": "P00001", "title": "Product 9", "width": -1}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"message":"width must be at least 0"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_updateProduct(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	title := "Product 6 XL"
+	svc.On("UpdateProduct", mock.Anything, "P13579", models.UpdateProductRequest{Title: &title}).Return(models.Product{
+		ID: 2, PartNumber: "P13579", Title: title, Width: 10, Height: 23, Depth: 15,
+	}, nil)
+
+	req, err := http.NewRequest("PATCH", "/products/P13579", bytes.NewBufferString(`{"title": "Product 6 XL"}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_archiveProduct(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("ArchiveProduct", mock.Anything, "P13579").Return(nil)
+
+	req, err := http.NewRequest("DELETE", "/products/P13579", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_nearestWarehouses(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	lat, lng := 58.0105, 56.2502
+	svc.On("NearestWarehouses", mock.Anything, models.NearestWarehousesRequest{
+		Latitude:    &lat,
+		Longitude:   &lng,
+		PartNumbers: []string{"P13579", "P97431"},
+		Limit:       2,
+	}).Return([]models.NearestWarehouse{
+		{
+			Warehouse: models.Warehouse{ID: 2, Title: "Warehouse H", Available: true, Latitude: 58.005939, Longitude: 56.210803},
+			Distance:  2342.7,
+			Stock:     []models.WarehouseStock{{PartNumber: "P13579", Quantity: 5}},
+		},
+	}, nil)
+
+	req, err := http.NewRequest("GET", "/warehouses/nearest?lat=58.0105&lng=56.2502&part_number=P13579&part_number=P97431&limit=2", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{
+		"id": 2,
+		"title": "Warehouse H",
+		"available": true,
+		"lat": 58.005939,
+		"long": 56.210803,
+		"distance": 2342.7,
+		"stock": [{"part_number": "P13579", "quantity": 5}]
+	}]`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_nearestWarehousesWithoutPoint(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("GET", "/warehouses/nearest?lat=58.0105", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"lng","rule":"required"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_nearestWarehousesInvalidPoint(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("GET", "/warehouses/nearest?lng=east&lat=north", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"lat","rule":"number"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_reservationQuote(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	quoteRequest := models.ReservationProductsRequest{
+		Items:     []models.ReservationItem{{PartNumber: "P13579", Quantity: 3}},
+		Latitude:  58.0105,
+		Longitude: 56.2502,
+	}
+	svc.On("ReservationQuote", mock.Anything, quoteRequest).Return(models.ReservationQuote{
+		QuoteToken: "eyJsaW5lcyI6W119.c2lnbmF0dXJl",
+		Available:  true,
+		Items: []models.ReservedItem{{
+			PartNumber: "P13579",
+			Quantity:   3,
+			Status:     models.ItemReserved,
+			Warehouses: []models.ReservedWarehouse{{WarehouseID: 2, Quantity: 3, Distance: 2383.9}},
+		}},
+	}, nil)
+
+	requestBody, _ := json.Marshal(quoteRequest)
+	req, err := http.NewRequest("POST", "/reservation-quotes", bytes.NewBuffer(requestBody))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"quote_token": "eyJsaW5lcyI6W119.c2lnbmF0dXJl",
+		"available": true,
+		"items": [{
+			"part_number": "P13579",
+			"quantity": 3,
+			"status": "reserved",
+			"warehouses": [{"warehouse_id": 2, "quantity": 3, "distance": 2383.9}]
+		}]
+	}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_redeemReservationQuoteStale(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("RedeemReservationQuote", mock.Anything, "eyJsaW5lcyI6W119.c2lnbmF0dXJl").Return(models.ReservationProductsResponse{},
+		fmt.Errorf("stock of product 2 in warehouse 2 has changed: %w", models.ErrQuoteStale))
+
+	req, err := http.NewRequest("POST", "/reservation-quotes/redeem", bytes.NewBufferString(`{"quote_token": "eyJsaW5lcyI6W119.c2lnbmF0dXJl"}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"code":"quote_stale"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_stockMovements(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	reservationID := uuid.MustParse("7d3c8e34-6f0e-4b5c-9a55-2f7f8f4a1c11")
+	svc.On("StockMovements", mock.Anything, 2, models.StockMovementsRequest{
+		From:   &from,
+		To:     &to,
+		Limit:  1,
+		Cursor: "10",
+	}).Return(models.StockMovementsPage{
+		Movements: []models.StockMovement{{
+			ID:            11,
+			PartNumber:    "P13579",
+			Kind:          models.MovementReserve,
+			Reserved:      3,
+			ReservationID: &reservationID,
+			CreatedAt:     time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		}},
+		NextCursor: "11",
+	}, nil)
+
+	req, err := http.NewRequest("GET", "/warehouses/2/movements?from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z&limit=1&cursor=10", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"movements": [{
+			"id": 11,
+			"part_number": "P13579",
+			"kind": "reserve",
+			"on_hand": 0,
+			"reserved": 3,
+			"reservation_id": "7d3c8e34-6f0e-4b5c-9a55-2f7f8f4a1c11",
+			"created_at": "2024-05-01T12:30:00Z"
+		}],
+		"next_cursor": "11"
+	}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_stockMovementsInvalidTime(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("GET", "/warehouses/2/movements?to=today&from=yesterday", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"from","rule":"rfc3339"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createReceipt(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	receiptRequest := models.CreateReceiptRequest{Items: []models.StockItem{{PartNumber: "P13579", Quantity: 5}}}
+	svc.On("CreateReceipt", mock.Anything, 1, receiptRequest).Return(models.Receipt{
+		ID:          3,
+		WarehouseID: 1,
+		Status:      models.ReceiptReceived,
+		Items:       receiptRequest.Items,
+		CreatedAt:   time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}, nil)
+
+	requestBody, _ := json.Marshal(receiptRequest)
+	req, err := http.NewRequest("POST", "/warehouses/1/receipts", bytes.NewBuffer(requestBody))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.JSONEq(t, `{
+		"id": 3,
+		"warehouse_id": 1,
+		"status": "received",
+		"items": [{"part_number": "P13579", "quantity": 5}],
+		"created_at": "2024-05-01T12:30:00Z"
+	}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createReceiptUnknownPartNumber(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	receiptRequest := models.CreateReceiptRequest{Items: []models.StockItem{{PartNumber: "P00000", Quantity: 5}}}
+	svc.On("CreateReceipt", mock.Anything, 1, receiptRequest).Return(models.Receipt{}, fmt.Errorf("P00000: %w", models.ErrUnknownPartNumber))
+
+	requestBody, _ := json.Marshal(receiptRequest)
+	req, err := http.NewRequest("POST", "/warehouses/1/receipts", bytes.NewBuffer(requestBody))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_changeTransferStatus(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	shippedAt := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	svc.On("ChangeTransferStatus", mock.Anything, 4, models.TransferInTransit).Return(models.Transfer{
+		ID:                     4,
+		SourceWarehouseID:      1,
+		DestinationWarehouseID: 2,
+		Status:                 models.TransferInTransit,
+		Items:                  []models.StockItem{{PartNumber: "P13579", Quantity: 5}},
+		CreatedAt:              time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		ShippedAt:              &shippedAt,
+	}, nil)
+
+	req, err := http.NewRequest("PATCH", "/transfers/4", bytes.NewBufferString(`{"status": "in_transit"}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"id": 4,
+		"source_warehouse_id": 1,
+		"destination_warehouse_id": 2,
+		"status": "in_transit",
+		"items": [{"part_number": "P13579", "quantity": 5}],
+		"created_at": "2024-05-01T10:00:00Z",
+		"shipped_at": "2024-05-01T12:30:00Z"
+	}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_changeTransferStatusConflict(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("ChangeTransferStatus", mock.Anything, 4, models.TransferReceived).
+		Return(models.Transfer{}, fmt.Errorf("transfer 4 is created: %w", models.ErrConflict))
+
+	req, err := http.NewRequest("PATCH", "/transfers/4", bytes.NewBufferString(`{"status": "received"}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_changeTransferStatusValidationFailed(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("PATCH", "/transfers/4", bytes.NewBufferString(`{"status": "created"}`))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"status","rule":"oneof"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createAdjustment(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	counted := 0
+	adjustmentRequest := models.CreateAdjustmentRequest{
+		Reason: models.AdjustmentLost,
+		Items:  []models.AdjustmentItem{{PartNumber: "P13579", Counted: &counted}},
+	}
+	svc.On("CreateAdjustment", mock.Anything, 1, adjustmentRequest).Return(models.Adjustment{
+		ID:          2,
+		WarehouseID: 1,
+		Reason:      models.AdjustmentLost,
+		Items:       []models.AdjustmentVariance{{PartNumber: "P13579", OnHand: 23, Counted: 0, Variance: -23}},
+		CreatedAt:   time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}, nil)
+
+	req, err := http.NewRequest("POST", "/warehouses/1/adjustments", bytes.NewBufferString(
+		`{"reason": "lost", "items": [{"part_number": "P13579", "counted": 0}]}`,
+	))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.JSONEq(t, `{
+		"id": 2,
+		"warehouse_id": 1,
+		"reason": "lost",
+		"items": [{"part_number": "P13579", "on_hand": 23, "counted": 0, "variance": -23, "reserved": 0}],
+		"created_at": "2024-05-01T12:30:00Z"
+	}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createAdjustmentBelowReserved(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("CreateAdjustment", mock.Anything, 1, mock.Anything).Return(
+		models.Adjustment{}, fmt.Errorf("counted quantity is below the reserved quantity of P13579: %w", models.ErrConflict),
+	)
+
+	req, err := http.NewRequest("POST", "/warehouses/1/adjustments", bytes.NewBufferString(
+		`{"reason": "cycle_count", "items": [{"part_number": "P13579", "counted": 1}]}`,
+	))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_createAdjustmentValidationFailed(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("POST", "/warehouses/1/adjustments", bytes.NewBufferString(
+		`{"items": [{"part_number": "P13579", "counted": 1}]}`,
+	))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"reason","rule":"required"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_lowStockAlerts(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	warehouseID := 2
+	svc.On("LowStockAlerts", mock.Anything, models.LowStockAlertsRequest{WarehouseID: &warehouseID}).Return([]models.LowStockAlert{{
+		ID:           1,
+		WarehouseID:  2,
+		ProductID:    3,
+		PartNumber:   "P97431",
+		ReorderPoint: 10,
+		Available:    4,
+		InTransit:    5,
+		CreatedAt:    time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}}, nil)
+
+	req, err := http.NewRequest("GET", "/alerts/low-stock?warehouse_id=2", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{
+		"id": 1,
+		"warehouse_id": 2,
+		"part_number": "P97431",
+		"reorder_point": 10,
+		"available": 4,
+		"in_transit": 5,
+		"created_at": "2024-05-01T12:30:00Z"
+	}]`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_setReorderPoints(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	point := 10
+	svc.On("SetReorderPoints", mock.Anything, 1, models.SetReorderPointsRequest{
+		Items: []models.ReorderPointItem{{PartNumber: "P13579", ReorderPoint: &point}},
+	}).Return([]models.ReorderPoint{{WarehouseID: 1, ProductID: 2, PartNumber: "P13579", ReorderPoint: 10}}, nil)
+
+	req, err := http.NewRequest("PUT", "/warehouses/1/reorder-points", bytes.NewBufferString(
+		`{"items": [{"part_number": "P13579", "reorder_point": 10}]}`,
+	))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"warehouse_id": 1, "part_number": "P13579", "reorder_point": 10}]`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_setReorderPointsValidationFailed(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	req, err := http.NewRequest("PUT", "/warehouses/1/reorder-points", bytes.NewBufferString(
+		`{"items": [{"part_number": "P13579", "reorder_point": -1}]}`,
+	))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"rule":"min"`)
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_productAvailability(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("ProductAvailability", mock.Anything, "P13579").Return(models.ProductAvailability{
+		Product: models.Product{ID: 2, PartNumber: "P13579", Title: "Product 2"},
+		Warehouses: []models.WarehouseAvailability{
+			{WarehouseID: 1, Title: "Warehouse 1", WarehouseAvail: true, StockQuantities: models.StockQuantities{OnHand: 23, Reserved: 3, Available: 20}},
+			{WarehouseID: 3, Title: "Warehouse 3", StockQuantities: models.StockQuantities{OnHand: 7, Available: 7, InTransit: 5}},
+		},
+		Total: models.StockQuantities{OnHand: 30, Reserved: 3, Available: 20, InTransit: 5},
+	}, nil)
+
+	req, err := http.NewRequest("GET", "/products/P13579/availability", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"product": {"id": 2, "part_number": "P13579", "title": "Product 2"},
+		"warehouses": [
+			{"warehouse_id": 1, "title": "Warehouse 1", "warehouse_available": true, "on_hand": 23, "reserved": 3, "available": 20, "in_transit": 0},
+			{"warehouse_id": 3, "title": "Warehouse 3", "warehouse_available": false, "on_hand": 7, "reserved": 0, "available": 7, "in_transit": 5}
+		],
+		"total": {"on_hand": 30, "reserved": 3, "available": 20, "in_transit": 5}
+	}`, rr.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestHandler_productAvailabilityNotFound(t *testing.T) {
+	svc := new(mockservice.ServiceMock)
+	h := handler.NewHandler(svc)
+
+	svc.On("ProductAvailability", mock.Anything, "P00000").Return(models.ProductAvailability{}, fmt.Errorf("product P00000: %w", models.ErrNotFound))
+
+	req, err := http.NewRequest("GET", "/products/P00000/availability", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	h.NewRoutes().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	svc.AssertExpectations(t)
+}
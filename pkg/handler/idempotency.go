@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// responseRecorder passes the response through and keeps a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// idempotent makes requests with the Idempotency-Key header safe to retry: the first response
+// for a key is stored and replayed for repeated requests with the same body.
+func (h *Handler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Errorf("error to read request: %v", err)
+			writeError(w, decodeError(err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		idempotencyKey := models.IdempotencyKey{
+			Key:         key,
+			Scope:       r.Method + " " + r.URL.Path,
+			RequestHash: hex.EncodeToString(hash[:]),
+			LeaseID:     uuid.New(),
+		}
+
+		stored, err := h.services.BeginIdempotentRequest(r.Context(), idempotencyKey)
+		if err != nil {
+			log.Errorf("error to begin idempotent request: %v", err)
+			writeError(w, err)
+			return
+		}
+
+		if stored != nil {
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			if _, err := w.Write(stored.Body); err != nil {
+				log.Errorf("error to write stored response: %v", err)
+			}
+			return
+		}
+
+		// the key is renewed while the request is handled, so a retry does not take it over and handle
+		// the request twice; if it is taken over anyway, the request is cancelled
+		leaseCtx, cancel := context.WithCancel(r.Context())
+		kept := make(chan struct{})
+		go func() {
+			defer close(kept)
+			if err := h.services.KeepIdempotentRequest(leaseCtx, idempotencyKey); err != nil {
+				log.Errorf("error to keep idempotent request: %v", err)
+				cancel()
+			}
+		}()
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next(rec, r.WithContext(leaseCtx))
+		cancel()
+		<-kept
+		if rec.statusCode == 0 {
+			rec.statusCode = http.StatusOK
+		}
+
+		// the response is already sent, so it must be stored even if the client has gone away
+		ctx := context.WithoutCancel(r.Context())
+
+		// server errors are not stored, so the request can be retried with the same key
+		if rec.statusCode >= http.StatusInternalServerError {
+			if err := h.services.AbortIdempotentRequest(ctx, idempotencyKey); err != nil {
+				log.Errorf("error to abort idempotent request: %v", err)
+			}
+			return
+		}
+
+		if err := h.services.FinishIdempotentRequest(ctx, idempotencyKey, models.IdempotentResponse{
+			StatusCode:  rec.statusCode,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}); err != nil {
+			log.Errorf("error to finish idempotent request: %v", err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+func (h *Handler) product(w http.ResponseWriter, r *http.Request) {
+	product, err := h.services.Product(r.Context(), chi.URLParam(r, "part_number"))
+	if err != nil {
+		log.Errorf("error to get product: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (h *Handler) productAvailability(w http.ResponseWriter, r *http.Request) {
+	availability, err := h.services.ProductAvailability(r.Context(), chi.URLParam(r, "part_number"))
+	if err != nil {
+		log.Errorf("error to get product availability: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, availability)
+}
+
+func (h *Handler) createProduct(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	product, err := h.services.CreateProduct(r.Context(), req)
+	if err != nil {
+		log.Errorf("error to create product: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, product)
+}
+
+func (h *Handler) updateProduct(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	product, err := h.services.UpdateProduct(r.Context(), chi.URLParam(r, "part_number"), req)
+	if err != nil {
+		log.Errorf("error to update product: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, product)
+}
+
+func (h *Handler) archiveProduct(w http.ResponseWriter, r *http.Request) {
+	if err := h.services.ArchiveProduct(r.Context(), chi.URLParam(r, "part_number")); err != nil {
+		log.Errorf("error to archive product: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
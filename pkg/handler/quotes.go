@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+)
+
+func (h *Handler) reservationQuote(w http.ResponseWriter, r *http.Request) {
+	var req models.ReservationProductsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	quote, err := h.services.ReservationQuote(r.Context(), req)
+	if err != nil {
+		log.Errorf("error to quote reservation: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, quote)
+}
+
+func (h *Handler) redeemReservationQuote(w http.ResponseWriter, r *http.Request) {
+	var req models.RedeemReservationQuoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	reservation, err := h.services.RedeemReservationQuote(r.Context(), req.QuoteToken)
+	if err != nil {
+		log.Errorf("error to redeem reservation quote: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
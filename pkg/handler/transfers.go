@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+func (h *Handler) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	transfer, err := h.services.CreateTransfer(r.Context(), req)
+	if err != nil {
+		log.Errorf("error to create transfer: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, transfer)
+}
+
+func (h *Handler) transfer(w http.ResponseWriter, r *http.Request) {
+	transferID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert transfer id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	transfer, err := h.services.Transfer(r.Context(), transferID)
+	if err != nil {
+		log.Errorf("error to get transfer: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfer)
+}
+
+func (h *Handler) changeTransferStatus(w http.ResponseWriter, r *http.Request) {
+	transferID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert transfer id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	var req models.TransferStatusRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err = h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	transfer, err := h.services.ChangeTransferStatus(r.Context(), transferID, req.Status)
+	if err != nil {
+		log.Errorf("error to change transfer status: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfer)
+}
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+func (h *Handler) warehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := h.services.Warehouses(r.Context())
+	if err != nil {
+		log.Errorf("error to get warehouses: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, warehouses)
+}
+
+func (h *Handler) warehouse(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	warehouse, err := h.services.Warehouse(r.Context(), warehouseID)
+	if err != nil {
+		log.Errorf("error to get warehouse: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, warehouse)
+}
+
+// defaultNearestWarehousesLimit is the number of nearest warehouses returned if the limit is not requested.
+const defaultNearestWarehousesLimit = 10
+
+func (h *Handler) nearestWarehouses(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := models.NearestWarehousesRequest{
+		PartNumbers: query["part_number"],
+		Limit:       defaultNearestWarehousesLimit,
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **float64
+	}{{"lat", &req.Latitude}, {"lng", &req.Longitude}} {
+		if val := query.Get(param.name); val != "" {
+			coordinate, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				log.Errorf("error to convert %s: %v", param.name, err)
+				writeError(w, paramError(param.name, "number", err))
+				return
+			}
+			*param.dst = &coordinate
+		}
+	}
+
+	if val := query.Get("limit"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			log.Errorf("error to convert limit: %v", err)
+			writeError(w, paramError("limit", "number", err))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	warehouses, err := h.services.NearestWarehouses(r.Context(), req)
+	if err != nil {
+		log.Errorf("error to get nearest warehouses: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, warehouses)
+}
+
+func (h *Handler) createWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWarehouseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	warehouse, err := h.services.CreateWarehouse(r.Context(), req)
+	if err != nil {
+		log.Errorf("error to create warehouse: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, warehouse)
+}
+
+func (h *Handler) updateWarehouse(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	var req models.UpdateWarehouseRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err = h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	warehouse, err := h.services.UpdateWarehouse(r.Context(), warehouseID, req)
+	if err != nil {
+		log.Errorf("error to update warehouse: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, warehouse)
+}
+
+func (h *Handler) deleteWarehouse(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	if err = h.services.DeleteWarehouse(r.Context(), warehouseID); err != nil {
+		log.Errorf("error to delete warehouse: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// defaultStockMovementsLimit is the size of a page of stock movements if the limit is not requested.
+const defaultStockMovementsLimit = 100
+
+func (h *Handler) stockMovements(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	query := r.URL.Query()
+	req := models.StockMovementsRequest{
+		Limit:  defaultStockMovementsLimit,
+		Cursor: query.Get("cursor"),
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &req.From}, {"to", &req.To}} {
+		if val := query.Get(param.name); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				log.Errorf("error to convert %s: %v", param.name, err)
+				writeError(w, paramError(param.name, "rfc3339", err))
+				return
+			}
+			*param.dst = &t
+		}
+	}
+
+	if val := query.Get("limit"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			log.Errorf("error to convert limit: %v", err)
+			writeError(w, paramError("limit", "number", err))
+			return
+		}
+		req.Limit = limit
+	}
+
+	if err = h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	movements, err := h.services.StockMovements(r.Context(), warehouseID, req)
+	if err != nil {
+		log.Errorf("error to get stock movements: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, movements)
+}
+
+func (h *Handler) createReceipt(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	var req models.CreateReceiptRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err = h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	receipt, err := h.services.CreateReceipt(r.Context(), warehouseID, req)
+	if err != nil {
+		log.Errorf("error to create receipt: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, receipt)
+}
+
+func (h *Handler) createAdjustment(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Errorf("error to convert warehouse id: %v", err)
+		writeError(w, paramError("id", "number", err))
+		return
+	}
+
+	var req models.CreateAdjustmentRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Errorf("error to decode request: %v", err)
+		writeError(w, decodeError(err))
+		return
+	}
+
+	if err = h.validate.Struct(req); err != nil {
+		log.Errorf("validation error: %v", err)
+		writeError(w, validationError(err))
+		return
+	}
+
+	adjustment, err := h.services.CreateAdjustment(r.Context(), warehouseID, req)
+	if err != nil {
+		log.Errorf("error to create adjustment: %v", err)
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, adjustment)
+}
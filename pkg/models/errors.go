@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ErrorCode string
+
+const (
+	CodeNotFound             ErrorCode = "not_found"
+	CodeInsufficientStock    ErrorCode = "insufficient_stock"
+	CodeValidationFailed     ErrorCode = "validation_failed"
+	CodeConflict             ErrorCode = "conflict"
+	CodeUnknownPartNumber    ErrorCode = "unknown_part_number"
+	CodeIdempotencyKeyReused ErrorCode = "idempotency_key_reused"
+	CodeQuoteStale           ErrorCode = "quote_stale"
+	CodeInternal             ErrorCode = "internal"
+)
+
+// Error is a sentinel error with a stable code reported to API clients.
+// Errors wrapping none of them are reported as internal.
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithDetail returns the error with a detail which is safe to report to API clients,
+// unlike the messages of errors wrapping it.
+func (e *Error) WithDetail(format string, args ...interface{}) error {
+	return &DetailError{Err: e, Detail: fmt.Sprintf(format, args...)}
+}
+
+// DetailError is a sentinel error with a detail, see Error.WithDetail.
+type DetailError struct {
+	Err    *Error
+	Detail string
+}
+
+func (e *DetailError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Detail, e.Err.Message)
+}
+
+func (e *DetailError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DetailError) PublicMessage() string {
+	return e.Error()
+}
+
+var (
+	ErrNotFound             = &Error{Code: CodeNotFound, Message: "not found"}
+	ErrInsufficientStock    = &Error{Code: CodeInsufficientStock, Message: "insufficient stock"}
+	ErrValidation           = &Error{Code: CodeValidationFailed, Message: "validation failed"}
+	ErrConflict             = &Error{Code: CodeConflict, Message: "conflict"}
+	ErrUnknownPartNumber    = &Error{Code: CodeUnknownPartNumber, Message: "unknown part number"}
+	ErrIdempotencyKeyReused = &Error{Code: CodeIdempotencyKeyReused, Message: "idempotency key is already used for another request"}
+	ErrQuoteStale           = &Error{Code: CodeQuoteStale, Message: "quote is expired or stock has changed"}
+)
+
+// FieldError describes a request field which failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		fields[i] = field.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(fields, "; "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+func (e *ValidationError) PublicMessage() string {
+	return e.Error()
+}
+
+// StatusConflictError reports reserved products which can not be moved to the requested status.
+type StatusConflictError struct {
+	Status ReservationStatus
+	Lines  []ReservationLine
+}
+
+func (e *StatusConflictError) Error() string {
+	lines := make([]string, len(e.Lines))
+	for i, line := range e.Lines {
+		lines[i] = fmt.Sprintf("%s in warehouse %d is %s", line.PartNumber, line.WarehouseID, line.Status)
+	}
+	return fmt.Sprintf("can not change status to %s: %s", e.Status, strings.Join(lines, ", "))
+}
+
+func (e *StatusConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+func (e *StatusConflictError) PublicMessage() string {
+	return e.Error()
+}
+
+// AllocationError reports requested lines which can not be reserved.
+type AllocationError struct {
+	Items []ReservedItem
+}
+
+func (e *AllocationError) Error() string {
+	var items []string
+	for _, item := range e.Items {
+		if item.Status != ItemReserved {
+			items = append(items, fmt.Sprintf("%s is %s", item.PartNumber, item.Status))
+		}
+	}
+	return fmt.Sprintf("can not reserve products: %s", strings.Join(items, ", "))
+}
+
+func (e *AllocationError) Unwrap() error {
+	for _, item := range e.Items {
+		if item.Status == ItemUnknown {
+			return ErrUnknownPartNumber
+		}
+	}
+	return ErrInsufficientStock
+}
+
+func (e *AllocationError) PublicMessage() string {
+	return e.Error()
+}
@@ -1,6 +1,10 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type ConfigPostgres struct {
 	User     string
@@ -15,10 +19,136 @@ type ConfigServer struct {
 	Port string
 }
 
+// ConfigIdempotency configures idempotency keys. An in-progress key is renewed while the request is handled
+// and taken over if it has not been renewed for Lease, so a request abandoned by a crashed process can be retried.
+// Keys are deleted after TTL.
+type ConfigIdempotency struct {
+	Lease time.Duration
+	TTL   time.Duration
+}
+
+const (
+	DefaultIdempotencyLease = time.Minute
+	DefaultIdempotencyTTL   = 24 * time.Hour
+)
+
+const (
+	DefaultShipmentCost  = 300
+	DefaultUnitCostPerKm = 0.05
+	DefaultExtraDistance = 50000
+)
+
+type ConfigReservation struct {
+	TTL                time.Duration
+	ExpirationInterval time.Duration
+	// Strategy is the allocation strategy of reservations which do not request one
+	Strategy AllocationStrategy
+	// ShipmentCost and UnitCostPerKm are the costs of the lowest_cost strategy: every warehouse
+	// a product is shipped from costs ShipmentCost plus UnitCostPerKm for every unit and kilometer
+	ShipmentCost  float64
+	UnitCostPerKm float64
+	// QuoteTTL is how long a reservation quote can be redeemed, QuoteSecret signs quote tokens
+	QuoteTTL    time.Duration
+	QuoteSecret []byte
+	// ExtraDistance is how much further in meters than the nearest warehouses
+	// the fewest_warehouses strategy may take products from, nil does not limit it
+	ExtraDistance *float64
+}
+
+type ReservationItem struct {
+	PartNumber string `json:"part_number" validate:"required"`
+	Quantity   int    `json:"quantity" validate:"required,min=1"`
+}
+
+type AllocationPolicy string
+
+const (
+	// PolicyAllOrNothing reserves nothing unless every line can be reserved.
+	PolicyAllOrNothing AllocationPolicy = "all_or_nothing"
+	// PolicyBestEffort reserves the lines which can be reserved and reports the rest.
+	PolicyBestEffort AllocationPolicy = "best_effort"
+)
+
+// AllocationStrategy chooses warehouses to reserve products from.
+type AllocationStrategy string
+
+const (
+	// StrategyNearest takes products from the nearest warehouses first.
+	StrategyNearest AllocationStrategy = "nearest"
+	// StrategyFewestWarehouses reserves the whole basket from as few warehouses as possible.
+	StrategyFewestWarehouses AllocationStrategy = "fewest_warehouses"
+	// StrategyLowestCost minimises the cost of shipments.
+	StrategyLowestCost AllocationStrategy = "lowest_cost"
+	// StrategyBalanceStock takes products from the warehouses with the most stock to even out stock levels.
+	StrategyBalanceStock AllocationStrategy = "balance_stock"
+)
+
 type ReservationProductsRequest struct {
-	PartNumbers []string `json:"part_numbers" validate:"required,min=1"`
-	Latitude    float64  `json:"latitude" validate:"required"`
-	Longitude   float64  `json:"longitude" validate:"required"`
+	Items     []ReservationItem  `json:"items" validate:"required,min=1,dive"`
+	Latitude  float64            `json:"latitude" validate:"required"`
+	Longitude float64            `json:"longitude" validate:"required"`
+	HoldTTL   int                `json:"hold_ttl,omitempty" validate:"omitempty,min=1"` // seconds
+	Policy    AllocationPolicy   `json:"policy,omitempty" validate:"omitempty,oneof=all_or_nothing best_effort"`
+	Strategy  AllocationStrategy `json:"strategy,omitempty" validate:"omitempty,oneof=nearest fewest_warehouses lowest_cost balance_stock"`
+}
+
+// ReservationQuote is the plan of a reservation which is not made. The plan can be reserved
+// with QuoteToken until ExpiresAt if the stock it is taken from does not change.
+type ReservationQuote struct {
+	QuoteToken string         `json:"quote_token,omitempty"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+	Available  bool           `json:"available"`
+	Items      []ReservedItem `json:"items"`
+}
+
+type RedeemReservationQuoteRequest struct {
+	QuoteToken string `json:"quote_token" validate:"required"`
+}
+
+type ReservedWarehouse struct {
+	WarehouseID int     `json:"warehouse_id"`
+	Quantity    int     `json:"quantity"`
+	Distance    float64 `json:"distance"`
+}
+
+type ItemStatus string
+
+const (
+	ItemReserved    ItemStatus = "reserved"
+	ItemUnknown     ItemStatus = "unknown"
+	ItemUnavailable ItemStatus = "unavailable"
+)
+
+type ReservedItem struct {
+	PartNumber string              `json:"part_number"`
+	Quantity   int                 `json:"quantity"`
+	Status     ItemStatus          `json:"status"`
+	Warehouses []ReservedWarehouse `json:"warehouses,omitempty"`
+}
+
+type ReservationProductsResponse struct {
+	ReservationID uuid.UUID      `json:"reservation_id"`
+	ExpiresAt     time.Time      `json:"expires_at"`
+	Items         []ReservedItem `json:"items"`
+}
+
+type ReservationLine struct {
+	WarehouseProductID int               `json:"-"`
+	PartNumber         string            `json:"part_number"`
+	Title              string            `json:"title"`
+	WarehouseID        int               `json:"warehouse_id"`
+	Warehouse          string            `json:"warehouse"`
+	Quantity           int               `json:"quantity"`
+	Status             ReservationStatus `json:"status"`
+	Distance           float64           `json:"distance"`
+	CreatedAt          time.Time         `json:"created_at"`
+	ExpiresAt          *time.Time        `json:"expires_at,omitempty"`
+}
+
+type Reservation struct {
+	ReservationID uuid.UUID         `json:"reservation_id"`
+	Status        string            `json:"status"`
+	Lines         []ReservationLine `json:"lines"`
 }
 
 type CancelORConfirmProductsRequest struct {
@@ -26,13 +156,257 @@ type CancelORConfirmProductsRequest struct {
 	PartNumbers   []string  `json:"part_numbers"`
 }
 
+type ReservationStatusRequest struct {
+	Status      *ReservationStatus `json:"status" validate:"required,requestable_status"`
+	PartNumbers []string           `json:"part_numbers"`
+}
+
 type Warehouse struct {
 	ID        int     `json:"id"`
 	Title     string  `json:"title"`
+	Available bool    `json:"available"`
 	Latitude  float64 `json:"lat"`
 	Longitude float64 `json:"long"`
 }
 
+// NearestWarehousesRequest is the query of GET /warehouses/nearest. With part numbers only warehouses
+// which have any of the products in stock are returned.
+type NearestWarehousesRequest struct {
+	Latitude    *float64 `json:"lat" validate:"required,latitude"`
+	Longitude   *float64 `json:"lng" validate:"required,longitude"`
+	PartNumbers []string `json:"part_number" validate:"dive,required"`
+	Limit       int      `json:"limit" validate:"min=1,max=100"`
+}
+
+type WarehouseStock struct {
+	PartNumber string `json:"part_number"`
+	Quantity   int    `json:"quantity"`
+}
+
+type NearestWarehouse struct {
+	Warehouse
+	Distance float64          `json:"distance"` // meters
+	Stock    []WarehouseStock `json:"stock,omitempty"`
+}
+
+type CreateWarehouseRequest struct {
+	Title     string   `json:"title" validate:"required"`
+	Available bool     `json:"available"`
+	Latitude  *float64 `json:"lat" validate:"required,latitude"`
+	Longitude *float64 `json:"long" validate:"required,longitude"`
+}
+
+// UpdateWarehouseRequest changes only the fields which are set.
+type UpdateWarehouseRequest struct {
+	Title     *string  `json:"title" validate:"omitempty,min=1"`
+	Available *bool    `json:"available"`
+	Latitude  *float64 `json:"lat" validate:"omitempty,latitude"`
+	Longitude *float64 `json:"long" validate:"omitempty,longitude"`
+}
+
+// MovementKind is the reason of a change of stock.
+type MovementKind string
+
+const (
+	// MovementReserve reserves stock on hand.
+	MovementReserve MovementKind = "reserve"
+	// MovementRelease releases the reserved stock of cancelled and expired reserved products.
+	MovementRelease MovementKind = "release"
+	// MovementConfirm takes confirmed reserved products from the stock on hand.
+	MovementConfirm MovementKind = "confirm"
+	// MovementReturn puts returned products back to stock.
+	MovementReturn MovementKind = "return"
+	// MovementReceipt adds received products to stock.
+	MovementReceipt MovementKind = "receipt"
+	// MovementAdjustment corrects stock to the counted quantity.
+	MovementAdjustment MovementKind = "adjustment"
+	// MovementTransfer moves products between warehouses.
+	MovementTransfer MovementKind = "transfer"
+)
+
+type StockMovement struct {
+	ID            int64        `json:"id"`
+	PartNumber    string       `json:"part_number"`
+	Kind          MovementKind `json:"kind"`
+	OnHand        int          `json:"on_hand"`  // signed change of the stock on hand
+	Reserved      int          `json:"reserved"` // signed change of the reserved stock
+	ReservationID *uuid.UUID   `json:"reservation_id,omitempty"`
+	ReceiptID     *int         `json:"receipt_id,omitempty"`
+	TransferID    *int         `json:"transfer_id,omitempty"`
+	AdjustmentID  *int         `json:"adjustment_id,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// StockMovementsRequest is the query of GET /warehouses/{id}/movements. From is inclusive, To is exclusive.
+type StockMovementsRequest struct {
+	From   *time.Time `json:"from"`
+	To     *time.Time `json:"to"`
+	Limit  int        `json:"limit" validate:"min=1,max=1000"`
+	Cursor string     `json:"cursor"`
+}
+
+// StockMovementsFilter selects a page of movements of a warehouse for the repository.
+// Movements are ordered by id, After is the id of the last movement of the previous page.
+type StockMovementsFilter struct {
+	WarehouseID int
+	From        *time.Time
+	To          *time.Time
+	After       int64
+	Limit       int
+}
+
+type StockMovementsPage struct {
+	Movements  []StockMovement `json:"movements"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// StockItem is a quantity of a product moved to or between warehouses.
+type StockItem struct {
+	PartNumber string `json:"part_number" validate:"required"`
+	Quantity   int    `json:"quantity" validate:"required,min=1"`
+}
+
+type CreateReceiptRequest struct {
+	Items []StockItem `json:"items" validate:"required,min=1,dive"`
+}
+
+type ReceiptStatus string
+
+// ReceiptReceived is the status of a receipt whose products are added to the stock on hand.
+const ReceiptReceived ReceiptStatus = "received"
+
+// Receipt is a document of products received by a warehouse.
+type Receipt struct {
+	ID          int           `json:"id"`
+	WarehouseID int           `json:"warehouse_id"`
+	Status      ReceiptStatus `json:"status"`
+	Items       []StockItem   `json:"items"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type CreateTransferRequest struct {
+	SourceWarehouseID      int         `json:"source_warehouse_id" validate:"required"`
+	DestinationWarehouseID int         `json:"destination_warehouse_id" validate:"required"`
+	Items                  []StockItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// TransferStatus is the status of a transfer. Transfers are created, then shipped by the source
+// warehouse and then received by the destination warehouse.
+type TransferStatus string
+
+const (
+	// TransferCreated is the status of a transfer which has not been shipped yet. It does not change stock.
+	TransferCreated TransferStatus = "created"
+	// TransferInTransit is the status of a shipped transfer. Its products are taken from the stock on hand
+	// of the source warehouse.
+	TransferInTransit TransferStatus = "in_transit"
+	// TransferReceived is the status of a received transfer. Its products are added to the stock on hand
+	// of the destination warehouse.
+	TransferReceived TransferStatus = "received"
+)
+
+// Previous returns the status a transfer must have to be moved to s.
+func (s TransferStatus) Previous() (TransferStatus, bool) {
+	switch s {
+	case TransferInTransit:
+		return TransferCreated, true
+	case TransferReceived:
+		return TransferInTransit, true
+	default:
+		return "", false
+	}
+}
+
+type TransferStatusRequest struct {
+	Status TransferStatus `json:"status" validate:"required,oneof=in_transit received"`
+}
+
+// Transfer is a document of products moved between warehouses.
+type Transfer struct {
+	ID                     int            `json:"id"`
+	SourceWarehouseID      int            `json:"source_warehouse_id"`
+	DestinationWarehouseID int            `json:"destination_warehouse_id"`
+	Status                 TransferStatus `json:"status"`
+	Items                  []StockItem    `json:"items"`
+	CreatedAt              time.Time      `json:"created_at"`
+	ShippedAt              *time.Time     `json:"shipped_at,omitempty"`
+	ReceivedAt             *time.Time     `json:"received_at,omitempty"`
+}
+
+// AdjustmentReason is the reason why the counted stock differs from the stock on hand.
+type AdjustmentReason string
+
+const (
+	AdjustmentCycleCount AdjustmentReason = "cycle_count"
+	AdjustmentDamaged    AdjustmentReason = "damaged"
+	AdjustmentLost       AdjustmentReason = "lost"
+	AdjustmentFound      AdjustmentReason = "found"
+	AdjustmentCorrection AdjustmentReason = "correction"
+)
+
+type AdjustmentItem struct {
+	PartNumber string `json:"part_number" validate:"required"`
+	Counted    *int   `json:"counted" validate:"required,min=0"`
+}
+
+type CreateAdjustmentRequest struct {
+	Reason AdjustmentReason `json:"reason" validate:"required,oneof=cycle_count damaged lost found correction"`
+	Items  []AdjustmentItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// AdjustmentVariance is the difference between the counted stock of a product and its stock on hand
+// before the adjustment.
+type AdjustmentVariance struct {
+	PartNumber string `json:"part_number"`
+	OnHand     int    `json:"on_hand"`
+	Counted    int    `json:"counted"`
+	Variance   int    `json:"variance"`
+	Reserved   int    `json:"reserved"`
+}
+
+// Adjustment is a document which corrects the stock on hand of a warehouse to the counted stock.
+type Adjustment struct {
+	ID          int                  `json:"id"`
+	WarehouseID int                  `json:"warehouse_id"`
+	Reason      AdjustmentReason     `json:"reason"`
+	Items       []AdjustmentVariance `json:"items"`
+	CreatedAt   time.Time            `json:"created_at"`
+}
+
+// ReorderPoint is the quantity of a product in a warehouse below which its available stock has to be replenished.
+type ReorderPoint struct {
+	WarehouseID  int    `json:"warehouse_id"`
+	ProductID    int    `json:"-"`
+	PartNumber   string `json:"part_number"`
+	ReorderPoint int    `json:"reorder_point"`
+}
+
+type ReorderPointItem struct {
+	PartNumber string `json:"part_number" validate:"required"`
+	// ReorderPoint 0 removes the reorder point of the product
+	ReorderPoint *int `json:"reorder_point" validate:"required,min=0"`
+}
+
+type SetReorderPointsRequest struct {
+	Items []ReorderPointItem `json:"items" validate:"required,min=1,dive"`
+}
+
+type LowStockAlertsRequest struct {
+	WarehouseID *int
+}
+
+// LowStockAlert is open while the available stock of a product in a warehouse is below its reorder point.
+type LowStockAlert struct {
+	ID           int       `json:"id"`
+	WarehouseID  int       `json:"warehouse_id"`
+	ProductID    int       `json:"-"`
+	PartNumber   string    `json:"part_number"`
+	ReorderPoint int       `json:"reorder_point"`
+	Available    int       `json:"available"`
+	InTransit    int       `json:"in_transit"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Product struct {
 	ID         int    `json:"id"`
 	PartNumber string `json:"part_number"`
@@ -42,19 +416,129 @@ type Product struct {
 	Depth      int    `json:"depth,omitempty"`
 }
 
+// ProductsRequest is the query of GET /products. Dimension ranges are inclusive.
+type ProductsRequest struct {
+	Limit      int    `json:"limit" validate:"min=1,max=500"`
+	Cursor     string `json:"cursor"`
+	PartNumber string `json:"part_number"` // prefix
+	Title      string `json:"title"`       // substring, case-insensitive
+	MinWidth   *int   `json:"min_width" validate:"omitempty,min=0"`
+	MaxWidth   *int   `json:"max_width" validate:"omitempty,min=0"`
+	MinHeight  *int   `json:"min_height" validate:"omitempty,min=0"`
+	MaxHeight  *int   `json:"max_height" validate:"omitempty,min=0"`
+	MinDepth   *int   `json:"min_depth" validate:"omitempty,min=0"`
+	MaxDepth   *int   `json:"max_depth" validate:"omitempty,min=0"`
+	// Sort is a field to sort by, prefixed with "-" for the descending order
+	Sort string `json:"sort" validate:"omitempty,oneof=id -id part_number -part_number title -title width -width height -height depth -depth"`
+}
+
+// ProductsCursor points to the last product of a page in the order of Sort.
+// Value is the value of the sort field of the product, it is empty when products are sorted by id.
+type ProductsCursor struct {
+	Sort  string `json:"sort"`
+	Value string `json:"value,omitempty"`
+	ID    int    `json:"id"`
+}
+
+// ProductsFilter selects a page of products for the repository.
+type ProductsFilter struct {
+	PartNumberPrefix string
+	Title            string
+	MinWidth         *int
+	MaxWidth         *int
+	MinHeight        *int
+	MaxHeight        *int
+	MinDepth         *int
+	MaxDepth         *int
+	SortField        string
+	Descending       bool
+	After            *ProductsCursor
+	Limit            int
+}
+
+type ProductsPage struct {
+	Products   []Product `json:"products"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+type CreateProductRequest struct {
+	PartNumber string `json:"part_number" validate:"required"`
+	Title      string `json:"title" validate:"required"`
+	Width      int    `json:"width" validate:"min=0"`
+	Height     int    `json:"height" validate:"min=0"`
+	Depth      int    `json:"depth" validate:"min=0"`
+}
+
+// UpdateProductRequest changes only the fields which are set.
+type UpdateProductRequest struct {
+	PartNumber *string `json:"part_number" validate:"omitempty,min=1"`
+	Title      *string `json:"title" validate:"omitempty,min=1"`
+	Width      *int    `json:"width" validate:"omitempty,min=0"`
+	Height     *int    `json:"height" validate:"omitempty,min=0"`
+	Depth      *int    `json:"depth" validate:"omitempty,min=0"`
+}
+
 type WarehouseProduct struct {
+	ID          int
 	ProductID   int
 	WarehouseID int
+	Quantity    int // available to reserve
 	Distance    float64
 }
 
 type ReservationProducts struct {
-	ProductID   int
-	WarehouseID int
+	WarehouseProductID int
+	ProductID          int
+	WarehouseID        int
+	Quantity           int
+	Distance           float64
 }
 
+// AvailabilityProducts is the stock of a product in a warehouse. Available is the stock on hand
+// which is not reserved, InTransit is the quantity shipped to the warehouse which has not been received yet.
 type AvailabilityProducts struct {
 	Product        Product
 	WarehouseAvail bool
-	Quantity       int
+	OnHand         int
+	Reserved       int
+	Available      int
+	InTransit      int
+}
+
+// StockQuantities are the quantities of the stock of a product, as in AvailabilityProducts.
+type StockQuantities struct {
+	OnHand    int `json:"on_hand"`
+	Reserved  int `json:"reserved"`
+	Available int `json:"available"`
+	InTransit int `json:"in_transit"`
+}
+
+// WarehouseAvailability is the stock of a product in a warehouse.
+type WarehouseAvailability struct {
+	WarehouseID    int    `json:"warehouse_id"`
+	Title          string `json:"title"`
+	WarehouseAvail bool   `json:"warehouse_available"`
+	StockQuantities
+}
+
+// ProductAvailability is the stock of a product in every warehouse. Total is the stock of the whole network,
+// its Available counts only the warehouses which are available, as nothing can be reserved in the others.
+type ProductAvailability struct {
+	Product    Product                 `json:"product"`
+	Warehouses []WarehouseAvailability `json:"warehouses"`
+	Total      StockQuantities         `json:"total"`
+}
+
+type IdempotencyKey struct {
+	Key         string
+	Scope       string
+	RequestHash string
+	LeaseID     uuid.UUID           // the request holding the key while it is in progress
+	Response    *IdempotentResponse // nil while the request is in progress
+}
+
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
 }
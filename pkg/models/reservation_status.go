@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type ReservationStatus int
+
+const (
+	StatusReserved ReservationStatus = iota
+	StatusCancelled
+	StatusConfirmed
+	StatusExpired
+	StatusShipped
+	StatusReturned
+)
+
+var reservationStatusNames = map[ReservationStatus]string{
+	StatusReserved:  "reserved",
+	StatusCancelled: "cancelled",
+	StatusConfirmed: "confirmed",
+	StatusExpired:   "expired",
+	StatusShipped:   "shipped",
+	StatusReturned:  "returned",
+}
+
+// reservationTransitions lists the statuses a reserved product may be moved to from each status.
+// Statuses missing from the table are final.
+var reservationTransitions = map[ReservationStatus][]ReservationStatus{
+	StatusReserved:  {StatusConfirmed, StatusCancelled, StatusExpired},
+	StatusConfirmed: {StatusShipped, StatusReturned},
+	StatusShipped:   {StatusReturned},
+}
+
+func ParseReservationStatus(name string) (ReservationStatus, error) {
+	for status, n := range reservationStatusNames {
+		if n == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown reservation status %q", name)
+}
+
+func (s ReservationStatus) String() string {
+	if name, ok := reservationStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("ReservationStatus(%d)", int(s))
+}
+
+func (s ReservationStatus) CanTransitionTo(next ReservationStatus) bool {
+	for _, status := range reservationTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Requestable reports whether clients may request to move reserved products to s.
+// Products are reserved only by reservations and expired only by their expiration.
+func (s ReservationStatus) Requestable() bool {
+	return s != StatusReserved && s != StatusExpired
+}
+
+// Sources returns the statuses from which a reserved product may be moved to s.
+func (s ReservationStatus) Sources() []ReservationStatus {
+	var sources []ReservationStatus
+	for from := range reservationTransitions {
+		if from.CanTransitionTo(s) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
+
+func (s ReservationStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s *ReservationStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("reservation status must be a string: %w", err)
+	}
+
+	status, err := ParseReservationStatus(name)
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
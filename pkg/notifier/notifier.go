@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+)
+
+// Log writes low stock alerts to the log.
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (n *Log) NotifyLowStock(_ context.Context, alerts []models.LowStockAlert) error {
+	for _, alert := range alerts {
+		log.Warnf(
+			"low stock of %s in warehouse %d: available %d, reorder point %d, in transit %d",
+			alert.PartNumber, alert.WarehouseID, alert.Available, alert.ReorderPoint, alert.InTransit,
+		)
+	}
+	return nil
+}
+
+// Webhook posts low stock alerts as JSON {"alerts": [...]} to url.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *Webhook) NotifyLowStock(ctx context.Context, alerts []models.LowStockAlert) error {
+	body, err := json.Marshal(struct {
+		Alerts []models.LowStockAlert `json:"alerts"`
+	}{Alerts: alerts})
+	if err != nil {
+		return fmt.Errorf("error to encode alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error to post alerts: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package notifier_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/Hymiside/lamoda-api/pkg/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_NotifyLowStock(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := notifier.NewWebhook(server.URL, time.Second).NotifyLowStock(context.Background(), []models.LowStockAlert{{
+		ID:           1,
+		WarehouseID:  2,
+		ProductID:    3,
+		PartNumber:   "P97431",
+		ReorderPoint: 10,
+		Available:    4,
+		CreatedAt:    time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"alerts": [{
+		"id": 1,
+		"warehouse_id": 2,
+		"part_number": "P97431",
+		"reorder_point": 10,
+		"available": 4,
+		"in_transit": 0,
+		"created_at": "2024-05-01T12:30:00Z"
+	}]}`, body)
+}
+
+func TestWebhook_NotifyLowStockFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := notifier.NewWebhook(server.URL, time.Second).NotifyLowStock(context.Background(), []models.LowStockAlert{{ID: 1}})
+	assert.ErrorContains(t, err, "502")
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/lib/pq"
+)
+
+// CreateAdjustment sets the stock on hand of the warehouse to the counted quantities of products by their ids
+// and returns the variance between them. Counts below the reserved stock of a product are rejected with
+// models.ErrConflict, as the reserved products must stay on hand until the reservations are released.
+func (r *Repository) CreateAdjustment(ctx context.Context, warehouseID int, reason models.AdjustmentReason, counted map[int]int) (models.Adjustment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Adjustment{}, fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = lockWarehouses(ctx, tx, warehouseID); err != nil {
+		return models.Adjustment{}, err
+	}
+
+	productIDs := make([]int, 0, len(counted))
+	for productID := range counted {
+		productIDs = append(productIDs, productID)
+	}
+
+	if _, err = createWarehouseProducts(ctx, tx, warehouseID, productIDs); err != nil {
+		return models.Adjustment{}, err
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT wp.id, wp.product_id, p.part_number, wp.on_hand, wp.reserved
+		FROM warehouse_products wp
+		JOIN products p ON wp.product_id = p.id
+		WHERE wp.warehouse_id = $1 AND wp.product_id = ANY($2)
+		ORDER BY wp.id
+		FOR UPDATE OF wp`,
+		warehouseID, pq.Array(productIDs))
+	if err != nil {
+		return models.Adjustment{}, fmt.Errorf("error to lock warehouse products: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		adjustment = models.Adjustment{WarehouseID: warehouseID, Reason: reason}
+		movements  []stockMovement
+		onHand     = make([]int, 0, len(counted))
+		values     = make([]int, 0, len(counted))
+		below      []string
+	)
+	productIDs = productIDs[:0]
+	for rows.Next() {
+		var (
+			warehouseProductID, productID int
+			item                          models.AdjustmentVariance
+		)
+		if err := rows.Scan(&warehouseProductID, &productID, &item.PartNumber, &item.OnHand, &item.Reserved); err != nil {
+			return models.Adjustment{}, fmt.Errorf("scan error: %w", err)
+		}
+		item.Counted = counted[productID]
+		item.Variance = item.Counted - item.OnHand
+		if item.Counted < item.Reserved {
+			below = append(below, fmt.Sprintf("%s (counted %d, reserved %d)", item.PartNumber, item.Counted, item.Reserved))
+		}
+
+		adjustment.Items = append(adjustment.Items, item)
+		productIDs = append(productIDs, productID)
+		onHand = append(onHand, item.OnHand)
+		values = append(values, item.Counted)
+		if item.Variance != 0 {
+			movements = append(movements, stockMovement{warehouseProductID: warehouseProductID, kind: models.MovementAdjustment, onHand: item.Variance})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Adjustment{}, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(below) > 0 {
+		sort.Strings(below)
+		return models.Adjustment{}, models.ErrConflict.WithDetail("counted quantity is below the reserved quantity of %s", strings.Join(below, ", "))
+	}
+
+	if err = tx.QueryRowContext(
+		ctx,
+		"insert into adjustments (warehouse_id, reason) values ($1, $2) returning id, created_at",
+		warehouseID, reason,
+	).Scan(&adjustment.ID, &adjustment.CreatedAt); err != nil {
+		return models.Adjustment{}, fmt.Errorf("error to create adjustment: %w", err)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO adjustment_items (adjustment_id, product_id, on_hand, counted)
+		SELECT $1, i.product_id, i.on_hand, i.counted
+		FROM unnest($2::int[], $3::int[], $4::int[]) AS i(product_id, on_hand, counted)`,
+		adjustment.ID, pq.Array(productIDs), pq.Array(onHand), pq.Array(values),
+	); err != nil {
+		return models.Adjustment{}, fmt.Errorf("error to create adjustment items: %w", err)
+	}
+
+	for i := range movements {
+		movements[i].adjustmentID = sql.NullInt64{Int64: int64(adjustment.ID), Valid: true}
+	}
+	if err = applyStockMovements(ctx, tx, movements); err != nil {
+		return models.Adjustment{}, fmt.Errorf("error to update warehouse products: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Adjustment{}, fmt.Errorf("error to commit tx: %w", err)
+	}
+
+	sort.Slice(adjustment.Items, func(i, j int) bool {
+		return adjustment.Items[i].PartNumber < adjustment.Items[j].PartNumber
+	})
+	return adjustment, nil
+}
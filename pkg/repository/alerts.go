@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/lib/pq"
+)
+
+// SetReorderPoints sets the reorder points of products by their ids in the warehouse.
+// Reorder point 0 removes the reorder point of the product.
+func (r *Repository) SetReorderPoints(ctx context.Context, warehouseID int, points map[int]int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = lockWarehouses(ctx, tx, warehouseID); err != nil {
+		return err
+	}
+
+	var productIDs, values, removed []int
+	for productID, point := range points {
+		if point == 0 {
+			removed = append(removed, productID)
+			continue
+		}
+		productIDs = append(productIDs, productID)
+		values = append(values, point)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		"delete from reorder_points where warehouse_id = $1 and product_id = ANY($2)",
+		warehouseID, pq.Array(removed),
+	); err != nil {
+		return fmt.Errorf("error to delete reorder points: %w", err)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO reorder_points (warehouse_id, product_id, reorder_point)
+		SELECT $1, p.product_id, p.reorder_point
+		FROM unnest($2::int[], $3::int[]) AS p(product_id, reorder_point)
+		ON CONFLICT (warehouse_id, product_id) DO UPDATE SET reorder_point = EXCLUDED.reorder_point`,
+		warehouseID, pq.Array(productIDs), pq.Array(values),
+	); err != nil {
+		return fmt.Errorf("error to set reorder points: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error to commit tx: %w", err)
+	}
+	return nil
+}
+
+// ReorderPoints returns the reorder points of the warehouse, or of all warehouses if warehouseID is 0,
+// ordered by warehouse and part number. Reorder points of archived products and deleted warehouses are skipped.
+func (r *Repository) ReorderPoints(ctx context.Context, warehouseID int) ([]models.ReorderPoint, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT rp.warehouse_id, rp.product_id, p.part_number, rp.reorder_point
+		FROM reorder_points rp
+		JOIN warehouses w ON rp.warehouse_id = w.id AND w.deleted_at IS NULL
+		JOIN products p ON rp.product_id = p.id AND p.archived_at IS NULL
+		WHERE $1 = 0 OR rp.warehouse_id = $1
+		ORDER BY rp.warehouse_id, p.part_number`,
+		warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]models.ReorderPoint, 0)
+	for rows.Next() {
+		var point models.ReorderPoint
+		if err := rows.Scan(&point.WarehouseID, &point.ProductID, &point.PartNumber, &point.ReorderPoint); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return points, nil
+}
+
+// lowStockAlertsLockID is the key of the advisory lock which serialises evaluations of low stock alerts,
+// so concurrent evaluators do not resolve and reopen the alerts of each other.
+const lowStockAlertsLockID = 0x6c6f7773746f636b // "lowstock"
+
+// UpdateLowStockAlerts evaluates the stock against reorder points: alerts of stock which is not below its
+// reorder point anymore are resolved, the open ones are refreshed and the rest are opened. Opened alerts
+// are not notified yet, see ClaimUnnotifiedLowStockAlerts.
+func (r *Repository) UpdateLowStockAlerts(ctx context.Context) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", int64(lowStockAlertsLockID)); err != nil {
+		return fmt.Errorf("error to lock low stock alerts: %w", err)
+	}
+
+	below, err := lowStock(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		warehouseIDs = make([]int, len(below))
+		productIDs   = make([]int, len(below))
+		points       = make([]int, len(below))
+		available    = make([]int, len(below))
+		inTransit    = make([]int, len(below))
+	)
+	for i, alert := range below {
+		warehouseIDs[i], productIDs[i] = alert.WarehouseID, alert.ProductID
+		points[i], available[i], inTransit[i] = alert.ReorderPoint, alert.Available, alert.InTransit
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		`UPDATE low_stock_alerts a
+		SET resolved_at = NOW()
+		WHERE a.resolved_at IS NULL AND NOT EXISTS (
+			SELECT 1
+			FROM unnest($1::int[], $2::int[]) AS b(warehouse_id, product_id)
+			WHERE b.warehouse_id = a.warehouse_id AND b.product_id = a.product_id
+		)`,
+		pq.Array(warehouseIDs), pq.Array(productIDs),
+	); err != nil {
+		return fmt.Errorf("error to resolve low stock alerts: %w", err)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		`UPDATE low_stock_alerts a
+		SET reorder_point = b.reorder_point, available = b.available, in_transit = b.in_transit
+		FROM unnest($1::int[], $2::int[], $3::int[], $4::int[], $5::int[])
+			AS b(warehouse_id, product_id, reorder_point, available, in_transit)
+		WHERE a.resolved_at IS NULL AND a.warehouse_id = b.warehouse_id AND a.product_id = b.product_id`,
+		pq.Array(warehouseIDs), pq.Array(productIDs), pq.Array(points), pq.Array(available), pq.Array(inTransit),
+	); err != nil {
+		return fmt.Errorf("error to refresh low stock alerts: %w", err)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO low_stock_alerts (warehouse_id, product_id, reorder_point, available, in_transit)
+		SELECT b.warehouse_id, b.product_id, b.reorder_point, b.available, b.in_transit
+		FROM unnest($1::int[], $2::int[], $3::int[], $4::int[], $5::int[])
+			AS b(warehouse_id, product_id, reorder_point, available, in_transit)
+		ON CONFLICT (warehouse_id, product_id) WHERE resolved_at IS NULL DO NOTHING`,
+		pq.Array(warehouseIDs), pq.Array(productIDs), pq.Array(points), pq.Array(available), pq.Array(inTransit),
+	); err != nil {
+		return fmt.Errorf("error to open low stock alerts: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error to commit tx: %w", err)
+	}
+	return nil
+}
+
+// ClaimUnnotifiedLowStockAlerts claims the open alerts which have not been notified, so concurrent evaluators
+// do not notify them too, and returns them ordered by id. Alerts claimed longer than lease ago, e.g. by
+// a crashed process, are claimed again. Claimed alerts are marked notified by SetLowStockAlertsNotified,
+// or released by ReleaseLowStockAlerts if the notification fails.
+func (r *Repository) ClaimUnnotifiedLowStockAlerts(ctx context.Context, lease time.Duration) ([]models.LowStockAlert, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`WITH claimed AS (
+			UPDATE low_stock_alerts
+			SET notify_claimed_at = NOW()
+			WHERE id IN (
+				SELECT id
+				FROM low_stock_alerts
+				WHERE resolved_at IS NULL AND notified_at IS NULL
+					AND (notify_claimed_at IS NULL OR notify_claimed_at < NOW() - make_interval(secs => $1))
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT
+			a.id,
+			a.warehouse_id,
+			a.product_id,
+			p.part_number,
+			a.reorder_point,
+			a.available,
+			a.in_transit,
+			a.created_at
+		FROM claimed a
+		JOIN products p ON a.product_id = p.id
+		ORDER BY a.id`,
+		lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("error to claim low stock alerts: %w", err)
+	}
+	return scanLowStockAlerts(rows)
+}
+
+// SetLowStockAlertsNotified marks the claimed alerts notified, so they are not notified again.
+func (r *Repository) SetLowStockAlertsNotified(ctx context.Context, ids []int) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		"update low_stock_alerts set notified_at = NOW() where id = ANY($1)",
+		pq.Array(ids),
+	); err != nil {
+		return fmt.Errorf("error to mark low stock alerts notified: %w", err)
+	}
+	return nil
+}
+
+// ReleaseLowStockAlerts releases the claim of alerts which failed to be notified, so the next evaluation notifies them.
+func (r *Repository) ReleaseLowStockAlerts(ctx context.Context, ids []int) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		"update low_stock_alerts set notify_claimed_at = NULL where id = ANY($1) and notified_at is null",
+		pq.Array(ids),
+	); err != nil {
+		return fmt.Errorf("error to release low stock alerts: %w", err)
+	}
+	return nil
+}
+
+// lowStock returns the stock below its reorder point, as AvailabilityProductsByWarehouseID counts it,
+// of products which are not archived in warehouses which are not deleted. Products a warehouse has never
+// had have no stock.
+func lowStock(ctx context.Context, tx *sql.Tx) ([]models.LowStockAlert, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT
+			rp.warehouse_id,
+			rp.product_id,
+			p.part_number,
+			rp.reorder_point,
+			COALESCE(wp.available, 0),
+			COALESCE(t.quantity, 0)
+		FROM reorder_points rp
+		JOIN warehouses w ON rp.warehouse_id = w.id AND w.deleted_at IS NULL
+		JOIN products p ON rp.product_id = p.id AND p.archived_at IS NULL
+		LEFT JOIN warehouse_products wp ON wp.warehouse_id = rp.warehouse_id AND wp.product_id = rp.product_id
+		LEFT JOIN (
+			SELECT t.destination_warehouse_id, ti.product_id, SUM(ti.quantity) AS quantity
+			FROM transfers t
+			JOIN transfer_items ti ON ti.transfer_id = t.id
+			WHERE t.status = $1
+			GROUP BY t.destination_warehouse_id, ti.product_id
+		) t ON t.destination_warehouse_id = rp.warehouse_id AND t.product_id = rp.product_id
+		WHERE COALESCE(wp.available, 0) < rp.reorder_point
+		ORDER BY rp.warehouse_id, p.part_number`,
+		models.TransferInTransit)
+	if err != nil {
+		return nil, fmt.Errorf("error to get low stock: %w", err)
+	}
+	defer rows.Close()
+
+	var below []models.LowStockAlert
+	for rows.Next() {
+		var alert models.LowStockAlert
+		if err := rows.Scan(
+			&alert.WarehouseID,
+			&alert.ProductID,
+			&alert.PartNumber,
+			&alert.ReorderPoint,
+			&alert.Available,
+			&alert.InTransit,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		below = append(below, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return below, nil
+}
+
+// lowStockAlertsQuery selects low stock alerts a with part numbers of their products.
+const lowStockAlertsQuery = `SELECT
+		a.id,
+		a.warehouse_id,
+		a.product_id,
+		p.part_number,
+		a.reorder_point,
+		a.available,
+		a.in_transit,
+		a.created_at
+	FROM low_stock_alerts a
+	JOIN products p ON a.product_id = p.id`
+
+// LowStockAlerts returns the open alerts of the warehouse, or of all warehouses if warehouseID is 0, ordered by id.
+func (r *Repository) LowStockAlerts(ctx context.Context, warehouseID int) ([]models.LowStockAlert, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		lowStockAlertsQuery+" WHERE a.resolved_at IS NULL AND ($1 = 0 OR a.warehouse_id = $1) ORDER BY a.id",
+		warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return scanLowStockAlerts(rows)
+}
+
+func scanLowStockAlerts(rows *sql.Rows) ([]models.LowStockAlert, error) {
+	defer rows.Close()
+
+	alerts := make([]models.LowStockAlert, 0)
+	for rows.Next() {
+		var alert models.LowStockAlert
+		if err := rows.Scan(
+			&alert.ID,
+			&alert.WarehouseID,
+			&alert.ProductID,
+			&alert.PartNumber,
+			&alert.ReorderPoint,
+			&alert.Available,
+			&alert.InTransit,
+			&alert.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return alerts, nil
+}
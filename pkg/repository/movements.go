@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// stockMovement is a change of the stock on hand and the reserved stock of a warehouse product.
+type stockMovement struct {
+	warehouseProductID int
+	kind               models.MovementKind
+	onHand             int
+	reserved           int
+	reservationID      uuid.NullUUID
+	receiptID          sql.NullInt64
+	transferID         sql.NullInt64
+	adjustmentID       sql.NullInt64
+}
+
+// applyStockMovements changes the stock of warehouse products by movements and records them.
+// Every transaction changing the stock locks warehouse_products rows in the order of their ids,
+// as applyStockMovements does, so that concurrent transactions do not deadlock.
+func applyStockMovements(ctx context.Context, tx *sql.Tx, movements []stockMovement) error {
+	type change struct{ onHand, reserved int }
+	changes := make(map[int]change)
+	for _, m := range movements {
+		c := changes[m.warehouseProductID]
+		changes[m.warehouseProductID] = change{onHand: c.onHand + m.onHand, reserved: c.reserved + m.reserved}
+	}
+
+	ids := make([]int, 0, len(changes))
+	onHand, reserved := make([]int, 0, len(changes)), make([]int, 0, len(changes))
+	for id, c := range changes {
+		ids = append(ids, id)
+		onHand = append(onHand, c.onHand)
+		reserved = append(reserved, c.reserved)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"SELECT id FROM warehouse_products WHERE id = ANY($1) ORDER BY id FOR UPDATE",
+		pq.Array(ids),
+	); err != nil {
+		return fmt.Errorf("error to lock warehouse_products: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`UPDATE warehouse_products wp
+		SET on_hand = wp.on_hand + c.on_hand, reserved = wp.reserved + c.reserved
+		FROM unnest($1::int[], $2::int[], $3::int[]) AS c(id, on_hand, reserved)
+		WHERE wp.id = c.id`,
+		pq.Array(ids), pq.Array(onHand), pq.Array(reserved),
+	); err != nil {
+		return fmt.Errorf("error to update stock in warehouse_products: %w", err)
+	}
+	return insertStockMovements(ctx, tx, movements)
+}
+
+// createWarehouseProducts creates stock of the products which the warehouse has never had and returns
+// ids of warehouse products by product ids. Existing stock is not locked, so it can be locked later
+// in the order of ids, see applyStockMovements.
+func createWarehouseProducts(ctx context.Context, tx *sql.Tx, warehouseID int, productIDs []int) (map[int]int, error) {
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO warehouse_products (warehouse_id, product_id, on_hand)
+		SELECT $1, product_id, 0
+		FROM unnest($2::int[]) AS product_id
+		ORDER BY product_id
+		ON CONFLICT (warehouse_id, product_id) DO NOTHING`,
+		warehouseID, pq.Array(productIDs),
+	); err != nil {
+		return nil, fmt.Errorf("error to create warehouse products: %w", err)
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		"select id, product_id from warehouse_products where warehouse_id = $1 and product_id = ANY($2)",
+		warehouseID, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error to get warehouse products: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]int, len(productIDs))
+	for rows.Next() {
+		var warehouseProductID, productID int
+		if err := rows.Scan(&warehouseProductID, &productID); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		ids[productID] = warehouseProductID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return ids, nil
+}
+
+// insertStockMovements appends movements to the ledger. It must be called in the transaction
+// which changes the quantities, so the ledger never disagrees with warehouse_products.
+func insertStockMovements(ctx context.Context, tx *sql.Tx, movements []stockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+
+	var (
+		ids            = make([]int, len(movements))
+		kinds          = make([]string, len(movements))
+		onHand         = make([]int, len(movements))
+		reserved       = make([]int, len(movements))
+		reservationIDs = make([]uuid.NullUUID, len(movements))
+		receiptIDs     = make([]sql.NullInt64, len(movements))
+		transferIDs    = make([]sql.NullInt64, len(movements))
+		adjustmentIDs  = make([]sql.NullInt64, len(movements))
+	)
+	for i, m := range movements {
+		ids[i], kinds[i] = m.warehouseProductID, string(m.kind)
+		onHand[i], reserved[i] = m.onHand, m.reserved
+		reservationIDs[i], receiptIDs[i], transferIDs[i], adjustmentIDs[i] = m.reservationID, m.receiptID, m.transferID, m.adjustmentID
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO stock_movements (
+			warehouse_id, warehouse_product_id, kind, on_hand, reserved, reservation_id, receipt_id, transfer_id, adjustment_id
+		)
+		SELECT wp.warehouse_id, wp.id, m.kind, m.on_hand, m.reserved, m.reservation_id, m.receipt_id, m.transfer_id, m.adjustment_id
+		FROM unnest($1::int[], $2::text[], $3::int[], $4::int[], $5::uuid[], $6::int[], $7::int[], $8::int[])
+			WITH ORDINALITY AS m(id, kind, on_hand, reserved, reservation_id, receipt_id, transfer_id, adjustment_id, n)
+		JOIN warehouse_products wp ON wp.id = m.id
+		ORDER BY m.n`,
+		pq.Array(ids), pq.Array(kinds), pq.Array(onHand), pq.Array(reserved),
+		pq.Array(reservationIDs), pq.Array(receiptIDs), pq.Array(transferIDs), pq.Array(adjustmentIDs),
+	); err != nil {
+		return fmt.Errorf("error to insert stock movements: %w", err)
+	}
+	return nil
+}
+
+// StockMovements returns a page of movements of the warehouse ordered by id. Movements of deleted
+// warehouses are returned as well, models.ErrNotFound is returned only if the warehouse has never existed.
+func (r *Repository) StockMovements(ctx context.Context, filter models.StockMovementsFilter) ([]models.StockMovement, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(
+		ctx,
+		"select exists(select 1 from warehouses where id = $1)",
+		filter.WarehouseID,
+	).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("error to check warehouse: %w", err)
+	}
+	if !exists {
+		return nil, models.ErrNotFound.WithDetail("warehouse %d", filter.WarehouseID)
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT
+			m.id,
+			p.part_number,
+			m.kind,
+			m.on_hand,
+			m.reserved,
+			m.reservation_id,
+			m.receipt_id,
+			m.transfer_id,
+			m.adjustment_id,
+			m.created_at
+		FROM stock_movements m
+		JOIN warehouse_products wp ON m.warehouse_product_id = wp.id
+		JOIN products p ON wp.product_id = p.id
+		WHERE m.warehouse_id = $1 AND m.id > $2
+			AND ($3::timestamptz IS NULL OR m.created_at >= $3)
+			AND ($4::timestamptz IS NULL OR m.created_at < $4)
+		ORDER BY m.id
+		LIMIT $5`,
+		filter.WarehouseID, filter.After, filter.From, filter.To, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		var (
+			movement      models.StockMovement
+			reservationID uuid.NullUUID
+			receiptID     sql.NullInt64
+			transferID    sql.NullInt64
+			adjustmentID  sql.NullInt64
+		)
+		if err := rows.Scan(
+			&movement.ID,
+			&movement.PartNumber,
+			&movement.Kind,
+			&movement.OnHand,
+			&movement.Reserved,
+			&reservationID,
+			&receiptID,
+			&transferID,
+			&adjustmentID,
+			&movement.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		if reservationID.Valid {
+			movement.ReservationID = &reservationID.UUID
+		}
+		if receiptID.Valid {
+			id := int(receiptID.Int64)
+			movement.ReceiptID = &id
+		}
+		if transferID.Valid {
+			id := int(transferID.Int64)
+			movement.TransferID = &id
+		}
+		if adjustmentID.Valid {
+			id := int(adjustmentID.Int64)
+			movement.AdjustmentID = &id
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return movements, nil
+}
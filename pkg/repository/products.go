@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the SQLSTATE of the unique_violation error.
+const uniqueViolation = "23505"
+
+// productError reports the violation of the unique part number as models.ErrConflict. Only part numbers
+// of products which are not archived are unique.
+func productError(partNumber string, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == "products_part_number_key" {
+		return models.ErrConflict.WithDetail("product %s already exists", partNumber)
+	}
+	return err
+}
+
+// productSortColumns maps sort fields of products to their columns and types.
+var productSortColumns = map[string]struct{ column, typ string }{
+	"part_number": {"part_number", "text"},
+	"title":       {"title", "text"},
+	"width":       {"width", "integer"},
+	"height":      {"height", "integer"},
+	"depth":       {"depth", "integer"},
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Products returns a page of not archived products selected by filter. Pages are selected by the keyset
+// (sort column, id) after filter.After, so the cost of a page does not depend on its offset.
+func (r *Repository) Products(ctx context.Context, filter models.ProductsFilter) ([]models.Product, error) {
+	var (
+		conditions = []string{"archived_at IS NULL"}
+		args       []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.PartNumberPrefix != "" {
+		conditions = append(conditions, "part_number LIKE "+arg(likeEscaper.Replace(filter.PartNumberPrefix)+"%"))
+	}
+	if filter.Title != "" {
+		conditions = append(conditions, "title ILIKE "+arg("%"+likeEscaper.Replace(filter.Title)+"%"))
+	}
+	for _, bound := range []struct {
+		column string
+		op     string
+		value  *int
+	}{
+		{"width", ">=", filter.MinWidth},
+		{"width", "<=", filter.MaxWidth},
+		{"height", ">=", filter.MinHeight},
+		{"height", "<=", filter.MaxHeight},
+		{"depth", ">=", filter.MinDepth},
+		{"depth", "<=", filter.MaxDepth},
+	} {
+		if bound.value != nil {
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", bound.column, bound.op, arg(*bound.value)))
+		}
+	}
+
+	op, direction := ">", "ASC"
+	if filter.Descending {
+		op, direction = "<", "DESC"
+	}
+
+	orderBy := "id " + direction
+	sortColumn, ok := productSortColumns[filter.SortField]
+	if ok {
+		orderBy = fmt.Sprintf("%s %s, id %s", sortColumn.column, direction, direction)
+	}
+
+	if filter.After != nil {
+		if ok {
+			conditions = append(conditions, fmt.Sprintf(
+				"(%s, id) %s (%s::%s, %s)",
+				sortColumn.column, op, arg(filter.After.Value), sortColumn.typ, arg(filter.After.ID)))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("id %s %s", op, arg(filter.After.ID)))
+		}
+	}
+
+	query := fmt.Sprintf(
+		`SELECT id, title, part_number, width, height, depth
+		FROM products
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s`,
+		strings.Join(conditions, " AND "), orderBy, arg(filter.Limit))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var products []models.Product
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(
+			&product.ID,
+			&product.Title,
+			&product.PartNumber,
+			&product.Width,
+			&product.Height,
+			&product.Depth,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		products = append(products, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return products, nil
+}
+
+func (r *Repository) ProductByPartNumber(ctx context.Context, partNumber string) (models.Product, error) {
+	product := models.Product{PartNumber: partNumber}
+	err := r.db.QueryRowContext(
+		ctx,
+		"select id, title, width, height, depth from products where part_number = $1 and archived_at is null",
+		partNumber,
+	).Scan(&product.ID, &product.Title, &product.Width, &product.Height, &product.Depth)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Product{}, models.ErrNotFound.WithDetail("product %s", partNumber)
+	}
+	if err != nil {
+		return models.Product{}, fmt.Errorf("query error: %w", err)
+	}
+	return product, nil
+}
+
+func (r *Repository) CreateProduct(ctx context.Context, product models.Product) (models.Product, error) {
+	if err := r.db.QueryRowContext(
+		ctx,
+		"insert into products (part_number, title, width, height, depth) values ($1, $2, $3, $4, $5) returning id",
+		product.PartNumber, product.Title, product.Width, product.Height, product.Depth,
+	).Scan(&product.ID); err != nil {
+		return models.Product{}, fmt.Errorf("error to create product: %w", productError(product.PartNumber, err))
+	}
+	return product, nil
+}
+
+func (r *Repository) UpdateProduct(ctx context.Context, partNumber string, req models.UpdateProductRequest) (models.Product, error) {
+	var product models.Product
+	err := r.db.QueryRowContext(
+		ctx,
+		`UPDATE products
+		SET
+			part_number = COALESCE($2, part_number),
+			title = COALESCE($3, title),
+			width = COALESCE($4, width),
+			height = COALESCE($5, height),
+			depth = COALESCE($6, depth)
+		WHERE part_number = $1 AND archived_at IS NULL
+		RETURNING id, part_number, title, width, height, depth`,
+		partNumber, req.PartNumber, req.Title, req.Width, req.Height, req.Depth,
+	).Scan(&product.ID, &product.PartNumber, &product.Title, &product.Width, &product.Height, &product.Depth)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Product{}, models.ErrNotFound.WithDetail("product %s", partNumber)
+	}
+	if err != nil {
+		if req.PartNumber != nil {
+			err = productError(*req.PartNumber, err)
+		}
+		return models.Product{}, fmt.Errorf("error to update product: %w", err)
+	}
+	return product, nil
+}
+
+// ArchiveProduct hides the product from the catalog and new reservations. It refuses with models.ErrConflict
+// while the product has reserved or confirmed products.
+func (r *Repository) ArchiveProduct(ctx context.Context, partNumber string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// waits for reservations which have locked the product in lockWarehouseProducts
+	var productID int
+	err = tx.QueryRowContext(
+		ctx,
+		"select id from products where part_number = $1 and archived_at is null for update",
+		partNumber,
+	).Scan(&productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound.WithDetail("product %s", partNumber)
+	}
+	if err != nil {
+		return fmt.Errorf("error to lock product: %w", err)
+	}
+
+	var active int
+	if err = tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*)
+		FROM reserved_products rp
+		JOIN warehouse_products wp ON rp.warehouse_product_id = wp.id
+		WHERE wp.product_id = $1 AND rp.status = ANY($2)`,
+		productID, pq.Array([]int{int(models.StatusReserved), int(models.StatusConfirmed)}),
+	).Scan(&active); err != nil {
+		return fmt.Errorf("error to count active reservations: %w", err)
+	}
+
+	if active > 0 {
+		return models.ErrConflict.WithDetail("product %s has %d active reservations", partNumber, active)
+	}
+
+	if _, err = tx.ExecContext(ctx, "update products set archived_at = NOW() where id = $1", productID); err != nil {
+		return fmt.Errorf("error to archive product: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error to commit tx: %w", err)
+	}
+	return nil
+}
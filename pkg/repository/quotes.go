@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RedeemQuote records the quote as redeemed and reports whether it has not been redeemed before.
+func (r *Repository) RedeemQuote(ctx context.Context, quoteID uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		"insert into redeemed_quotes (quote_id) values ($1) on conflict (quote_id) do nothing",
+		quoteID)
+	if err != nil {
+		return false, fmt.Errorf("error to redeem quote: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// DeleteRedeemedQuote forgets the redemption of a quote whose plan has not been reserved,
+// so it can be redeemed again.
+func (r *Repository) DeleteRedeemedQuote(ctx context.Context, quoteID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, "delete from redeemed_quotes where quote_id = $1", quoteID); err != nil {
+		return fmt.Errorf("error to delete redeemed quote: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredRedeemedQuotes deletes quotes redeemed longer than ttl ago, whose tokens are expired.
+func (r *Repository) DeleteExpiredRedeemedQuotes(ctx context.Context, ttl time.Duration) (int, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		"delete from redeemed_quotes where redeemed_at < NOW() - make_interval(secs => $1)",
+		ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error to delete expired redeemed quotes: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error to get affected rows: %w", err)
+	}
+	return int(affected), nil
+}
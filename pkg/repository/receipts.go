@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/lib/pq"
+)
+
+// CreateReceipt records a receipt of quantities of products by their ids and adds them to the stock on hand
+// of the warehouse. Stock of products which the warehouse has never had is created.
+func (r *Repository) CreateReceipt(ctx context.Context, warehouseID int, quantities map[int]int) (models.Receipt, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Receipt{}, fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = lockWarehouses(ctx, tx, warehouseID); err != nil {
+		return models.Receipt{}, err
+	}
+
+	receipt := models.Receipt{WarehouseID: warehouseID, Status: models.ReceiptReceived}
+	if err = tx.QueryRowContext(
+		ctx,
+		"insert into receipts (warehouse_id, status) values ($1, $2) returning id, created_at",
+		warehouseID, receipt.Status,
+	).Scan(&receipt.ID, &receipt.CreatedAt); err != nil {
+		return models.Receipt{}, fmt.Errorf("error to create receipt: %w", err)
+	}
+
+	productIDs, values := make([]int, 0, len(quantities)), make([]int, 0, len(quantities))
+	for productID, quantity := range quantities {
+		productIDs = append(productIDs, productID)
+		values = append(values, quantity)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO receipt_items (receipt_id, product_id, quantity)
+		SELECT $1, i.product_id, i.quantity
+		FROM unnest($2::int[], $3::int[]) AS i(product_id, quantity)`,
+		receipt.ID, pq.Array(productIDs), pq.Array(values),
+	); err != nil {
+		return models.Receipt{}, fmt.Errorf("error to create receipt items: %w", err)
+	}
+
+	warehouseProductIDs, err := createWarehouseProducts(ctx, tx, warehouseID, productIDs)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	movements := make([]stockMovement, 0, len(quantities))
+	for productID, quantity := range quantities {
+		movements = append(movements, stockMovement{
+			warehouseProductID: warehouseProductIDs[productID],
+			kind:               models.MovementReceipt,
+			onHand:             quantity,
+			receiptID:          sql.NullInt64{Int64: int64(receipt.ID), Valid: true},
+		})
+	}
+
+	if err = applyStockMovements(ctx, tx, movements); err != nil {
+		return models.Receipt{}, fmt.Errorf("error to update warehouse products: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Receipt{}, fmt.Errorf("error to commit tx: %w", err)
+	}
+	return receipt, nil
+}
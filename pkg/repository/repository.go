@@ -3,8 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Hymiside/lamoda-api/pkg/models"
 	"github.com/google/uuid"
@@ -19,67 +22,98 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) ProductsIDsByPartNumbers(ctx context.Context, partNumbers []string) ([]int, error) {
-	queryParams, vals := make([]string, len(partNumbers)), make([]interface{}, len(partNumbers))
-	for i, v := range partNumbers {
-		queryParams[i], vals[i] = fmt.Sprintf("$%d", i+1), v
-	}
-
-	query := fmt.Sprintf("select id from products where part_number in (%s)", strings.Join(queryParams, ","))
-	rows, err := r.db.QueryContext(ctx, query, vals...)
+func (r *Repository) ProductsByPartNumbers(ctx context.Context, partNumbers []string) ([]models.Product, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"select id, part_number, title from products where part_number = ANY($1) and archived_at is null",
+		pq.Array(partNumbers))
 	if err != nil {
-		return nil, fmt.Errorf("query error: %v", err)
+		return nil, fmt.Errorf("query error: %w", err)
 	}
 	defer rows.Close()
 
-	var ids []int
+	var products []models.Product
 	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
-			return nil, fmt.Errorf("scan error: %v", err)
+		var product models.Product
+		if err := rows.Scan(&product.ID, &product.PartNumber, &product.Title); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
 		}
-		ids = append(ids, id)
+		products = append(products, product)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %v", err)
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
-	return ids, nil
+	return products, nil
 }
 
+// requestPoint is the point of latitude $1 and longitude $2. PostGIS points are (longitude, latitude).
+const requestPoint = `ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography`
+
+// warehouseDistance is the geodesic distance in meters on the WGS 84 spheroid
+// from the point ($1, $2) to the warehouse w.
+const warehouseDistance = `ST_Distance(w.location, ` + requestPoint + `)`
+
+// warehousesByProductIDsQuery selects stock of products $3 in available warehouses
+// with the distance from the point ($1, $2).
+const warehousesByProductIDsQuery = `SELECT
+		wp.id,
+		wp.product_id,
+		wp.warehouse_id,
+		wp.available,
+		` + warehouseDistance + ` AS distance
+	FROM warehouse_products wp
+	JOIN warehouses w ON wp.warehouse_id = w.id AND w.available = true AND w.deleted_at IS NULL
+	JOIN products p ON wp.product_id = p.id AND p.archived_at IS NULL
+	WHERE wp.product_id = ANY($3) AND wp.available > 0`
+
 func (r *Repository) WarehousesByProductIDs(ctx context.Context, productIDs []int, lat, long float64) ([]models.WarehouseProduct, error) {
-	queryParams, values := make([]string, len(productIDs)), []interface{}{lat, long}
-	for i := 0; i < len(productIDs); i++ {
-		queryParams[i] = fmt.Sprintf("$%d", i+3)
-		values = append(values, productIDs[i])
+	rows, err := r.db.QueryContext(ctx, warehousesByProductIDsQuery+" ORDER BY distance", lat, long, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("query error: %v", err)
 	}
+	defer rows.Close()
 
-	query := fmt.Sprintf(
-		`SELECT
-			wp.product_id,
-			wp.warehouse_id,
-			ST_Distance(
-				ST_Transform(ST_SetSRID(ST_MakePoint($1, $2), 4326), 3857), 
-				ST_Transform(ST_SetSRID(ST_MakePoint(w.lat, w.lng), 4326), 3857)
-			) AS distance
-		FROM warehouse_products wp
-		JOIN warehouses w ON wp.warehouse_id = w.id AND available = true
-		WHERE wp.product_id IN (%s) AND wp.quantity > 0
-		ORDER BY distance`,
-		strings.Join(queryParams, ","))
+	return scanWarehouseProducts(rows)
+}
+
+// lockWarehouseProducts locks stock of the products in available warehouses for update and returns it
+// ordered by the distance. Rows are locked in the order of their ids to avoid deadlocks between
+// reservations from different places. Warehouses and products are locked for share, so they can not be
+// made unavailable, deleted or archived until the transaction ends. With skipLocked rows locked by other
+// transactions are not returned.
+func (r *Repository) lockWarehouseProducts(ctx context.Context, tx *sql.Tx, productIDs []int, lat, long float64, skipLocked bool) ([]models.WarehouseProduct, error) {
+	query := warehousesByProductIDsQuery + " ORDER BY wp.id FOR UPDATE OF wp FOR SHARE OF w, p"
+	if skipLocked {
+		query = warehousesByProductIDsQuery + " ORDER BY wp.id FOR UPDATE OF wp SKIP LOCKED FOR SHARE OF w, p SKIP LOCKED"
+	}
 
-	rows, err := r.db.QueryContext(ctx, query, values...)
+	rows, err := tx.QueryContext(ctx, query, lat, long, pq.Array(productIDs))
 	if err != nil {
-		return nil, fmt.Errorf("query error: %v", err)
+		return nil, fmt.Errorf("query error: %w", err)
 	}
 	defer rows.Close()
 
+	warehouses, err := scanWarehouseProducts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(warehouses, func(i, j int) bool {
+		return warehouses[i].Distance < warehouses[j].Distance
+	})
+	return warehouses, nil
+}
+
+func scanWarehouseProducts(rows *sql.Rows) ([]models.WarehouseProduct, error) {
 	var warehouses []models.WarehouseProduct
 	for rows.Next() {
 		var wh models.WarehouseProduct
 		if err := rows.Scan(
+			&wh.ID,
 			&wh.ProductID,
 			&wh.WarehouseID,
+			&wh.Quantity,
 			&wh.Distance,
 		); err != nil {
 			return nil, fmt.Errorf("scan error: %v", err)
@@ -93,106 +127,168 @@ func (r *Repository) WarehousesByProductIDs(ctx context.Context, productIDs []in
 	return warehouses, nil
 }
 
-func (r *Repository) SetProductsToReserved(ctx context.Context, reservationID uuid.UUID, warehousesProducts map[int]int) (uuid.UUID, error) {
-	var warehouseIDs, productIDs []int
-	for pID, wID := range warehousesProducts {
-		productIDs = append(productIDs, pID)
-		warehouseIDs = append(warehouseIDs, wID)
-	}
-
+// SetProductsToReserved reserves the products chosen by plan from the stock of productIDs nearest to
+// the point (lat, long). The stock is locked for the whole transaction, so concurrent reservations
+// can not take the same products. Plan is called first with the stock which is not locked by concurrent
+// reservations, so contention falls through to the next-nearest warehouses. If plan fails on it, the locks
+// are released and plan is called once more with the whole stock after the concurrent reservations are
+// finished. Releasing the locks keeps every lock of the transaction in the order of ids.
+func (r *Repository) SetProductsToReserved(
+	ctx context.Context,
+	reservationID uuid.UUID,
+	ttl time.Duration,
+	productIDs []int,
+	lat, long float64,
+	plan func(warehouses []models.WarehouseProduct) ([]models.ReservationProducts, error),
+) (time.Time, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error to begin tx: %w", err)
+		return time.Time{}, fmt.Errorf("error to begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
-	warehouseProductIDs, err := r.warehouseProductIDs(ctx, tx, warehouseIDs, productIDs)
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT skip_locked"); err != nil {
+		return time.Time{}, fmt.Errorf("error to create savepoint: %w", err)
+	}
+
+	warehouses, err := r.lockWarehouseProducts(ctx, tx, productIDs, lat, long, true)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error to get warehouse product ids: %w", err)
+		return time.Time{}, fmt.Errorf("error to lock warehouse products: %w", err)
+	}
+
+	products, err := plan(warehouses)
+	if err != nil {
+		if _, err = tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT skip_locked"); err != nil {
+			return time.Time{}, fmt.Errorf("error to release warehouse products: %w", err)
+		}
+
+		if warehouses, err = r.lockWarehouseProducts(ctx, tx, productIDs, lat, long, false); err != nil {
+			return time.Time{}, fmt.Errorf("error to lock warehouse products: %w", err)
+		}
+
+		if products, err = plan(warehouses); err != nil {
+			return time.Time{}, err
+		}
 	}
 
-	queryParams, values := make([]string, len(warehouseProductIDs)), make([]interface{}, 0, len(warehouseProductIDs)*3)
-	for i, j := 0, 0; i < len(warehouseProductIDs); i, j = i+1, j+3 {
-		queryParams[i] = fmt.Sprintf("($%d, $%d, $%d)", j+1, j+2, j+3)
-		values = append(values, reservationID, warehouseProductIDs[i], 1)
+	queryParams, values := make([]string, len(products)), make([]interface{}, 0, len(products)*3+2)
+	values = append(values, reservationID, ttl.Seconds())
+	for i, j := 0, 2; i < len(products); i, j = i+1, j+3 {
+		queryParams[i] = fmt.Sprintf("($1, $%d, $%d, $%d, NOW() + make_interval(secs => $2))", j+1, j+2, j+3)
+		values = append(values, products[i].WarehouseProductID, products[i].Quantity, products[i].Distance)
 	}
 
 	query := fmt.Sprintf(
-		`INSERT INTO reserved_products (reservation_id, warehouse_product_id, quantity) 
+		`INSERT INTO reserved_products (reservation_id, warehouse_product_id, quantity, distance, expires_at) 
 		VALUES %s 
-		RETURNING reservation_id`,
+		RETURNING expires_at`,
 		strings.Join(queryParams, ", "))
-	row := tx.QueryRowContext(ctx, query, values...)
-	if row.Err() != nil {
-		return uuid.Nil, fmt.Errorf("error to set products to reserved: %w", row.Err())
-	}
-
-	var rID uuid.UUID
-	if err = row.Scan(&rID); err != nil {
-		return uuid.Nil, fmt.Errorf("scan error: %w", err)
-	}
 
-	query = "update warehouse_products set quantity = quantity - 1 where warehouse_id = ANY($1) and product_id = ANY($2)"
-	if _, err = tx.ExecContext(ctx, query, pq.Array(warehouseIDs), pq.Array(productIDs)); err != nil {
-		return uuid.Nil, fmt.Errorf("error to update quantity in warehouse_products: %w", err)
+	var expiresAt time.Time
+	if err = tx.QueryRowContext(ctx, query, values...).Scan(&expiresAt); err != nil {
+		return time.Time{}, fmt.Errorf("error to set products to reserved: %w", err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return uuid.Nil, fmt.Errorf("commit error: %w", err)
-	}
-	return rID, nil
-}
+	for _, p := range products {
+		res, err := tx.ExecContext(
+			ctx,
+			"update warehouse_products set reserved = reserved + $1 where id = $2 and available >= $1",
+			p.Quantity, p.WarehouseProductID)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("error to update reserved in warehouse_products: %w", err)
+		}
 
-func (r *Repository) warehouseProductIDs(ctx context.Context, tx *sql.Tx, warehouseIDs, productIDs []int) ([]int, error) {
-	query := "select id from warehouse_products where warehouse_id = ANY($1) and product_id = ANY($2)"
-	rows, err := tx.QueryContext(ctx, query, pq.Array(warehouseIDs), pq.Array(productIDs))
-	if err != nil {
-		return nil, fmt.Errorf("error to get warehousesids: %w", err)
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("error to get affected rows: %w", err)
+		}
+		if affected == 0 {
+			return time.Time{}, fmt.Errorf("not enough quantity of product %d in warehouse %d: %w", p.ProductID, p.WarehouseID, models.ErrInsufficientStock)
+		}
 	}
-	defer rows.Close()
 
-	var warehouseProductIDs []int
-	for rows.Next() {
-		var id int
-		if err := rows.Scan(&id); err != nil {
-			return nil, fmt.Errorf("scan error: %w", err)
+	movements := make([]stockMovement, len(products))
+	for i, p := range products {
+		movements[i] = stockMovement{
+			warehouseProductID: p.WarehouseProductID,
+			kind:               models.MovementReserve,
+			reserved:           p.Quantity,
+			reservationID:      uuid.NullUUID{UUID: reservationID, Valid: true},
 		}
-		warehouseProductIDs = append(warehouseProductIDs, id)
+	}
+	if err = insertStockMovements(ctx, tx, movements); err != nil {
+		return time.Time{}, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+	if err = tx.Commit(); err != nil {
+		return time.Time{}, fmt.Errorf("commit error: %w", err)
 	}
-	return warehouseProductIDs, nil
+	return expiresAt, nil
 }
 
-func (r *Repository) Products(ctx context.Context) ([]models.Product, error) {
-	rows, err := r.db.QueryContext(ctx, "select id, title, part_number, width, height, depth from products")
+func (r *Repository) ReservedProductsByReservationID(ctx context.Context, reservationID uuid.UUID) ([]models.ReservationLine, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT
+			wp.id,
+			p.part_number,
+			p.title,
+			w.id,
+			w.title,
+			rp.quantity,
+			rp.status,
+			COALESCE(rp.distance, 0),
+			rp.created_at,
+			rp.expires_at
+		FROM reserved_products rp
+		JOIN warehouse_products wp ON rp.warehouse_product_id = wp.id
+		JOIN warehouses w ON wp.warehouse_id = w.id
+		JOIN products p ON wp.product_id = p.id
+		WHERE rp.reservation_id = $1
+		ORDER BY p.part_number, rp.distance`,
+		reservationID)
 	if err != nil {
-		return nil, fmt.Errorf("query error: %v", err)
+		return nil, fmt.Errorf("query error: %w", err)
 	}
 	defer rows.Close()
 
-	var products []models.Product
+	var lines []models.ReservationLine
 	for rows.Next() {
-		var product models.Product
+		var line models.ReservationLine
 		if err := rows.Scan(
-			&product.ID,
-			&product.Title,
-			&product.PartNumber,
-			&product.Width,
-			&product.Height,
-			&product.Depth,
+			&line.WarehouseProductID,
+			&line.PartNumber,
+			&line.Title,
+			&line.WarehouseID,
+			&line.Warehouse,
+			&line.Quantity,
+			&line.Status,
+			&line.Distance,
+			&line.CreatedAt,
+			&line.ExpiresAt,
 		); err != nil {
-			return nil, fmt.Errorf("scan error: %v", err)
+			return nil, fmt.Errorf("scan error: %w", err)
 		}
-		products = append(products, product)
+		lines = append(lines, line)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %v", err)
+		return nil, fmt.Errorf("rows error: %w", err)
 	}
-	return products, nil
+	return lines, nil
+}
+
+// statusMovements are the movements of stock made when reserved products are moved to a status, with the changes
+// of the stock on hand and the reserved stock per unit. Confirmed products leave the stock on hand, so nothing
+// is changed when they are shipped.
+var statusMovements = map[models.ReservationStatus]struct {
+	kind             models.MovementKind
+	onHand, reserved int
+}{
+	models.StatusCancelled: {kind: models.MovementRelease, reserved: -1},
+	models.StatusExpired:   {kind: models.MovementRelease, reserved: -1},
+	models.StatusConfirmed: {kind: models.MovementConfirm, onHand: -1, reserved: -1},
+	models.StatusReturned:  {kind: models.MovementReturn, onHand: 1},
 }
 
 func (r *Repository) AvailabilityProductsByWarehouseID(ctx context.Context, warehouseID int) ([]models.AvailabilityProducts, error) {
@@ -202,13 +298,23 @@ func (r *Repository) AvailabilityProductsByWarehouseID(ctx context.Context, ware
 			p.id,
 			p.part_number,
 			p.title,
-			wp.quantity,
+			wp.on_hand,
+			wp.reserved,
+			wp.available,
+			COALESCE(t.quantity, 0),
 			w.available
 		FROM warehouse_products wp
 		JOIN warehouses w ON wp.warehouse_id = w.id
-		JOIN products p ON wp.product_id = p.id
+		JOIN products p ON wp.product_id = p.id AND p.archived_at IS NULL
+		LEFT JOIN (
+			SELECT ti.product_id, SUM(ti.quantity) AS quantity
+			FROM transfers t
+			JOIN transfer_items ti ON ti.transfer_id = t.id
+			WHERE t.destination_warehouse_id = $1 AND t.status = $2
+			GROUP BY ti.product_id
+		) t ON t.product_id = wp.product_id
 		WHERE wp.warehouse_id = $1`,
-		warehouseID)
+		warehouseID, models.TransferInTransit)
 	if err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
@@ -221,7 +327,10 @@ func (r *Repository) AvailabilityProductsByWarehouseID(ctx context.Context, ware
 			&availabilityProduct.Product.ID,
 			&availabilityProduct.Product.PartNumber,
 			&availabilityProduct.Product.Title,
-			&availabilityProduct.Quantity,
+			&availabilityProduct.OnHand,
+			&availabilityProduct.Reserved,
+			&availabilityProduct.Available,
+			&availabilityProduct.InTransit,
 			&availabilityProduct.WarehouseAvail,
 		); err != nil {
 			return nil, fmt.Errorf("scan error: %w", err)
@@ -235,14 +344,75 @@ func (r *Repository) AvailabilityProductsByWarehouseID(ctx context.Context, ware
 	return availabilityProducts, nil
 }
 
-func (r *Repository) SetProductsToConfirmedOrCanceledByProductIDs(ctx context.Context, status int, reservationData models.CancelORConfirmProductsRequest) error {
-	productIDs, err := r.ProductsIDsByPartNumbers(ctx, reservationData.PartNumbers)
+// AvailabilityByProductID returns the stock of the product in every warehouse which is not deleted, ordered by
+// warehouse id, with the total of the network. Warehouses which have never had the product are listed with no stock.
+func (r *Repository) AvailabilityByProductID(ctx context.Context, productID int) (models.ProductAvailability, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT
+			w.id,
+			w.title,
+			w.available,
+			COALESCE(wp.on_hand, 0),
+			COALESCE(wp.reserved, 0),
+			COALESCE(wp.available, 0),
+			COALESCE(t.quantity, 0),
+			SUM(COALESCE(wp.on_hand, 0)) OVER (),
+			SUM(COALESCE(wp.reserved, 0)) OVER (),
+			SUM(CASE WHEN w.available THEN COALESCE(wp.available, 0) ELSE 0 END) OVER (),
+			SUM(COALESCE(t.quantity, 0)) OVER ()
+		FROM warehouses w
+		LEFT JOIN warehouse_products wp ON wp.warehouse_id = w.id AND wp.product_id = $1
+		LEFT JOIN (
+			SELECT t.destination_warehouse_id, SUM(ti.quantity) AS quantity
+			FROM transfers t
+			JOIN transfer_items ti ON ti.transfer_id = t.id
+			WHERE ti.product_id = $1 AND t.status = $2
+			GROUP BY t.destination_warehouse_id
+		) t ON t.destination_warehouse_id = w.id
+		WHERE w.deleted_at IS NULL
+		ORDER BY w.id`,
+		productID, models.TransferInTransit)
 	if err != nil {
-		return fmt.Errorf("error to get products ids: %w", err)
+		return models.ProductAvailability{}, fmt.Errorf("query error: %w", err)
 	}
+	defer rows.Close()
 
-	if len(productIDs) == 0 {
-		return nil
+	availability := models.ProductAvailability{Warehouses: make([]models.WarehouseAvailability, 0)}
+	for rows.Next() {
+		var warehouse models.WarehouseAvailability
+		if err := rows.Scan(
+			&warehouse.WarehouseID,
+			&warehouse.Title,
+			&warehouse.WarehouseAvail,
+			&warehouse.OnHand,
+			&warehouse.Reserved,
+			&warehouse.Available,
+			&warehouse.InTransit,
+			&availability.Total.OnHand,
+			&availability.Total.Reserved,
+			&availability.Total.Available,
+			&availability.Total.InTransit,
+		); err != nil {
+			return models.ProductAvailability{}, fmt.Errorf("scan error: %w", err)
+		}
+		availability.Warehouses = append(availability.Warehouses, warehouse)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.ProductAvailability{}, fmt.Errorf("rows error: %w", err)
+	}
+	return availability, nil
+}
+
+// SetReservedProductsStatus moves reserved products of the reservation to status, changing the stock
+// of warehouses as statusMovements describe. If any of them is not in a status from which status is
+// reachable, or its hold has already expired, nothing is changed and models.ErrConflict is returned.
+func (r *Repository) SetReservedProductsStatus(ctx context.Context, reservationID uuid.UUID, warehouseProductIDs []int, status models.ReservationStatus) error {
+	sources := status.Sources()
+	sourceIDs := make([]int, len(sources))
+	for i, source := range sources {
+		sourceIDs[i] = int(source)
 	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -253,38 +423,45 @@ func (r *Repository) SetProductsToConfirmedOrCanceledByProductIDs(ctx context.Co
 
 	rows, err := tx.QueryContext(
 		ctx,
-		`UPDATE reserved_products rp
+		`UPDATE reserved_products
 		SET status = $1
-		FROM warehouse_products wp
-		JOIN warehouses w ON wp.warehouse_id = w.id
-		JOIN products p ON wp.product_id = p.id
-		WHERE rp.warehouse_product_id = wp.id AND rp.reservation_id = $2 AND wp.product_id = ANY($3) AND rp.status = 0
-		RETURNING rp.warehouse_product_id`,
-		status, reservationData.ReservationID, pq.Array(productIDs),
-	)
+		WHERE reservation_id = $2 AND warehouse_product_id = ANY($3) AND status = ANY($4)
+			AND (status <> $5 OR expires_at IS NULL OR expires_at > NOW())
+		RETURNING warehouse_product_id, quantity`,
+		status, reservationID, pq.Array(warehouseProductIDs), pq.Array(sourceIDs), models.StatusReserved)
 	if err != nil {
-		return fmt.Errorf("error to set products to confirmed: %w", err)
+		return fmt.Errorf("error to set status of reserved products: %w", err)
 	}
 
-	var warehouseProductIDs []int
+	movement, moves := statusMovements[status]
+
+	var movements []stockMovement
 	for rows.Next() {
-		var warehouseProductID int
-		if err := rows.Scan(&warehouseProductID); err != nil {
+		var warehouseProductID, quantity int
+		if err := rows.Scan(&warehouseProductID, &quantity); err != nil {
 			return fmt.Errorf("scan error: %w", err)
 		}
-		warehouseProductIDs = append(warehouseProductIDs, warehouseProductID)
+		movements = append(movements, stockMovement{
+			warehouseProductID: warehouseProductID,
+			kind:               movement.kind,
+			onHand:             movement.onHand * quantity,
+			reserved:           movement.reserved * quantity,
+			reservationID:      uuid.NullUUID{UUID: reservationID, Valid: true},
+		})
 	}
 
 	if err := rows.Err(); err != nil {
 		return fmt.Errorf("rows error: %w", err)
 	}
 
-	if _, err := tx.ExecContext(
-		ctx,
-		`UPDATE warehouse_products SET quantity = quantity + 1 WHERE id = ANY($1)`,
-		pq.Array(warehouseProductIDs),
-	); err != nil {
-		return fmt.Errorf("error to update warehouse products: %w", err)
+	if len(movements) != len(warehouseProductIDs) {
+		return fmt.Errorf("%d of %d reserved products have been changed: %w", len(warehouseProductIDs)-len(movements), len(warehouseProductIDs), models.ErrConflict)
+	}
+
+	if moves {
+		if err := applyStockMovements(ctx, tx, movements); err != nil {
+			return fmt.Errorf("error to update warehouse products: %w", err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -294,45 +471,183 @@ func (r *Repository) SetProductsToConfirmedOrCanceledByProductIDs(ctx context.Co
 	return nil
 }
 
-func (r *Repository) SetProductsToConfirmedOrCanceled(ctx context.Context, status int, reservationID uuid.UUID) error {
+// ExpireReservedProducts moves at most limit overdue reserved products to the expired status
+// and releases their reserved stock. Rows locked by another transaction are skipped,
+// so several instances can run it against the same database at the same time.
+func (r *Repository) ExpireReservedProducts(ctx context.Context, limit int) (int, error) {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("error to begin tx: %w", err)
+		return 0, fmt.Errorf("error to begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
 	rows, err := tx.QueryContext(
 		ctx,
-		`UPDATE reserved_products SET status = $1 WHERE reservation_id = $2 and status = 0 RETURNING warehouse_product_id`,
-		status, reservationID)
+		`UPDATE reserved_products rp
+		SET status = $2
+		FROM (
+			SELECT reservation_id, warehouse_product_id
+			FROM reserved_products
+			WHERE status = $3 AND expires_at <= NOW()
+			ORDER BY expires_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) expired
+		WHERE rp.reservation_id = expired.reservation_id AND rp.warehouse_product_id = expired.warehouse_product_id
+		RETURNING rp.reservation_id, rp.warehouse_product_id, rp.quantity`,
+		limit, models.StatusExpired, models.StatusReserved)
 	if err != nil {
-		return fmt.Errorf("error to set products to confirmed: %w", err)
+		return 0, fmt.Errorf("error to set products to expired: %w", err)
 	}
 
-	var warehouseProductIDs []int
+	var movements []stockMovement
 	for rows.Next() {
-		var warehouseProductID int
-		if err := rows.Scan(&warehouseProductID); err != nil {
-			return fmt.Errorf("scan error: %w", err)
+		m := stockMovement{kind: models.MovementRelease}
+		if err := rows.Scan(&m.reservationID, &m.warehouseProductID, &m.reserved); err != nil {
+			return 0, fmt.Errorf("scan error: %w", err)
 		}
-		warehouseProductIDs = append(warehouseProductIDs, warehouseProductID)
+		m.reserved = -m.reserved
+		movements = append(movements, m)
 	}
 
 	if err := rows.Err(); err != nil {
-		return fmt.Errorf("rows error: %w", err)
+		return 0, fmt.Errorf("rows error: %w", err)
 	}
 
-	if _, err := tx.ExecContext(
-		ctx,
-		`UPDATE warehouse_products SET quantity = quantity + 1 WHERE id = ANY($1)`,
-		pq.Array(warehouseProductIDs),
-	); err != nil {
-		return fmt.Errorf("error to set products to confirmed: %w", err)
+	if len(movements) == 0 {
+		return 0, nil
+	}
+
+	if err := applyStockMovements(ctx, tx, movements); err != nil {
+		return 0, fmt.Errorf("error to update warehouse products: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("error to commit tx: %w", err)
+		return 0, fmt.Errorf("error to commit tx: %w", err)
+	}
+	return len(movements), nil
+}
+
+// CreateIdempotencyKey saves the key without a response and reports whether it has not existed before.
+// A key which is in progress and has not been renewed for longer than lease is abandoned, e.g. by a crashed
+// process, so it is taken over as if it has not existed.
+func (r *Repository) CreateIdempotencyKey(ctx context.Context, key models.IdempotencyKey, lease time.Duration) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`INSERT INTO idempotency_keys (key, scope, request_hash, lease_id)
+		VALUES ($1, $2, $3, $5)
+		ON CONFLICT (key, scope) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash, lease_id = EXCLUDED.lease_id, created_at = NOW(), renewed_at = NOW()
+		WHERE idempotency_keys.status_code IS NULL
+			AND idempotency_keys.renewed_at < NOW() - make_interval(secs => $4)`,
+		key.Key, key.Scope, key.RequestHash, lease.Seconds(), key.LeaseID)
+	if err != nil {
+		return false, fmt.Errorf("error to create idempotency key: %w", err)
 	}
 
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+func (r *Repository) IdempotencyKey(ctx context.Context, key, scope string) (models.IdempotencyKey, error) {
+	var (
+		idempotencyKey = models.IdempotencyKey{Key: key, Scope: scope}
+		statusCode     sql.NullInt64
+		contentType    sql.NullString
+		response       []byte
+	)
+	err := r.db.QueryRowContext(
+		ctx,
+		"select request_hash, status_code, content_type, response from idempotency_keys where key = $1 and scope = $2",
+		key, scope,
+	).Scan(&idempotencyKey.RequestHash, &statusCode, &contentType, &response)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.IdempotencyKey{}, fmt.Errorf("idempotency key %q: %w", key, models.ErrNotFound)
+	}
+	if err != nil {
+		return models.IdempotencyKey{}, fmt.Errorf("query error: %w", err)
+	}
+
+	if statusCode.Valid {
+		idempotencyKey.Response = &models.IdempotentResponse{
+			StatusCode:  int(statusCode.Int64),
+			ContentType: contentType.String,
+			Body:        response,
+		}
+	}
+	return idempotencyKey, nil
+}
+
+// RenewIdempotencyKey renews the lease of the key in progress and reports whether it is still held by key.LeaseID.
+func (r *Repository) RenewIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (bool, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE idempotency_keys
+		SET renewed_at = NOW()
+		WHERE key = $1 AND scope = $2 AND lease_id = $3 AND status_code IS NULL`,
+		key.Key, key.Scope, key.LeaseID)
+	if err != nil {
+		return false, fmt.Errorf("error to renew idempotency key: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// SetIdempotencyKeyResponse stores the response of the key held by key.LeaseID.
+// It fails with models.ErrConflict if the key has been taken over.
+func (r *Repository) SetIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) error {
+	res, err := r.db.ExecContext(
+		ctx,
+		`UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response = $5
+		WHERE key = $1 AND scope = $2 AND lease_id = $6`,
+		key.Key, key.Scope, key.Response.StatusCode, key.Response.ContentType, key.Response.Body, key.LeaseID)
+	if err != nil {
+		return fmt.Errorf("error to set idempotency key response: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return models.ErrConflict.WithDetail("idempotency key %q has been taken over", key.Key)
+	}
+	return nil
+}
+
+// DeleteIdempotencyKey deletes the key held by key.LeaseID, a key taken over by another request is kept.
+func (r *Repository) DeleteIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error {
+	if _, err := r.db.ExecContext(
+		ctx,
+		"delete from idempotency_keys where key = $1 and scope = $2 and lease_id = $3",
+		key.Key, key.Scope, key.LeaseID,
+	); err != nil {
+		return fmt.Errorf("error to delete idempotency key: %w", err)
+	}
 	return nil
 }
+
+// DeleteExpiredIdempotencyKeys deletes the keys created longer than ttl ago and returns their number.
+func (r *Repository) DeleteExpiredIdempotencyKeys(ctx context.Context, ttl time.Duration) (int, error) {
+	res, err := r.db.ExecContext(
+		ctx,
+		"delete from idempotency_keys where created_at < NOW() - make_interval(secs => $1)",
+		ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error to delete expired idempotency keys: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error to get affected rows: %w", err)
+	}
+	return int(affected), nil
+}
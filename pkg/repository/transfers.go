@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/lib/pq"
+)
+
+// CreateTransfer records a transfer of quantities of products by their ids between the warehouses
+// of transfer. The stock is not changed until the transfer is shipped.
+func (r *Repository) CreateTransfer(ctx context.Context, transfer models.Transfer, quantities map[int]int) (models.Transfer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = lockWarehouses(ctx, tx, transfer.SourceWarehouseID, transfer.DestinationWarehouseID); err != nil {
+		return models.Transfer{}, err
+	}
+
+	transfer.Status = models.TransferCreated
+	if err = tx.QueryRowContext(
+		ctx,
+		`INSERT INTO transfers (source_warehouse_id, destination_warehouse_id, status)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`,
+		transfer.SourceWarehouseID, transfer.DestinationWarehouseID, transfer.Status,
+	).Scan(&transfer.ID, &transfer.CreatedAt); err != nil {
+		return models.Transfer{}, fmt.Errorf("error to create transfer: %w", err)
+	}
+
+	productIDs, values := make([]int, 0, len(quantities)), make([]int, 0, len(quantities))
+	for productID, quantity := range quantities {
+		productIDs = append(productIDs, productID)
+		values = append(values, quantity)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO transfer_items (transfer_id, product_id, quantity)
+		SELECT $1, i.product_id, i.quantity
+		FROM unnest($2::int[], $3::int[]) AS i(product_id, quantity)`,
+		transfer.ID, pq.Array(productIDs), pq.Array(values),
+	); err != nil {
+		return models.Transfer{}, fmt.Errorf("error to create transfer items: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.Transfer{}, fmt.Errorf("error to commit tx: %w", err)
+	}
+	return transfer, nil
+}
+
+func (r *Repository) TransferByID(ctx context.Context, transferID int) (models.Transfer, error) {
+	transfer := models.Transfer{ID: transferID}
+	err := r.db.QueryRowContext(
+		ctx,
+		`SELECT source_warehouse_id, destination_warehouse_id, status, created_at, shipped_at, received_at
+		FROM transfers
+		WHERE id = $1`,
+		transferID,
+	).Scan(
+		&transfer.SourceWarehouseID,
+		&transfer.DestinationWarehouseID,
+		&transfer.Status,
+		&transfer.CreatedAt,
+		&transfer.ShippedAt,
+		&transfer.ReceivedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Transfer{}, models.ErrNotFound.WithDetail("transfer %d", transferID)
+	}
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("query error: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT p.part_number, ti.quantity
+		FROM transfer_items ti
+		JOIN products p ON ti.product_id = p.id
+		WHERE ti.transfer_id = $1
+		ORDER BY p.part_number`,
+		transferID)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.StockItem
+		if err := rows.Scan(&item.PartNumber, &item.Quantity); err != nil {
+			return models.Transfer{}, fmt.Errorf("scan error: %w", err)
+		}
+		transfer.Items = append(transfer.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Transfer{}, fmt.Errorf("rows error: %w", err)
+	}
+	return transfer, nil
+}
+
+// SetTransferStatus moves the transfer to status. Shipping takes its products from the stock on hand
+// of the source warehouse and fails with models.ErrInsufficientStock if they are not available there.
+// Receiving adds them to the stock on hand of the destination warehouse. models.ErrConflict is returned
+// if the transfer can not be moved to status from its current status.
+func (r *Repository) SetTransferStatus(ctx context.Context, transferID int, status models.TransferStatus) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var (
+		sourceID, destinationID int
+		current                 models.TransferStatus
+	)
+	err = tx.QueryRowContext(
+		ctx,
+		"select source_warehouse_id, destination_warehouse_id, status from transfers where id = $1 for update",
+		transferID,
+	).Scan(&sourceID, &destinationID, &current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound.WithDetail("transfer %d", transferID)
+	}
+	if err != nil {
+		return fmt.Errorf("error to lock transfer: %w", err)
+	}
+
+	if previous, ok := status.Previous(); !ok || current != previous {
+		return models.ErrConflict.WithDetail("transfer %d is %s, can not change status to %s", transferID, current, status)
+	}
+
+	quantities, err := transferQuantities(ctx, tx, transferID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		movements []stockMovement
+		column    string
+	)
+	switch status {
+	case models.TransferInTransit:
+		movements, err = shipTransfer(ctx, tx, sourceID, destinationID, quantities)
+		column = "shipped_at"
+	case models.TransferReceived:
+		movements, err = receiveTransfer(ctx, tx, destinationID, quantities)
+		column = "received_at"
+	}
+	if errors.Is(err, models.ErrNotFound) {
+		return fmt.Errorf("%v: %w", err, models.ErrConflict.WithDetail("a warehouse of transfer %d is deleted", transferID))
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range movements {
+		movements[i].transferID = sql.NullInt64{Int64: int64(transferID), Valid: true}
+	}
+	if err = applyStockMovements(ctx, tx, movements); err != nil {
+		return fmt.Errorf("error to update warehouse products: %w", err)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		fmt.Sprintf("update transfers set status = $2, %s = NOW() where id = $1", column),
+		transferID, status,
+	); err != nil {
+		return fmt.Errorf("error to set status of transfer: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error to commit tx: %w", err)
+	}
+	return nil
+}
+
+// transferQuantities returns the quantities of products of the transfer by product ids.
+func transferQuantities(ctx context.Context, tx *sql.Tx, transferID int) (map[int]int, error) {
+	rows, err := tx.QueryContext(ctx, "select product_id, quantity from transfer_items where transfer_id = $1", transferID)
+	if err != nil {
+		return nil, fmt.Errorf("error to get transfer items: %w", err)
+	}
+	defer rows.Close()
+
+	quantities := make(map[int]int)
+	for rows.Next() {
+		var productID, quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		quantities[productID] = quantity
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return quantities, nil
+}
+
+// shipTransfer returns the movements which take quantities from the available stock of the source warehouse.
+// Stock of the destination warehouse is created, so the products in transit are listed in its availability.
+// It fails with models.ErrNotFound if any of the warehouses is deleted.
+func shipTransfer(ctx context.Context, tx *sql.Tx, sourceID, destinationID int, quantities map[int]int) ([]stockMovement, error) {
+	if err := lockWarehouses(ctx, tx, sourceID, destinationID); err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]int, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+
+	if _, err := createWarehouseProducts(ctx, tx, destinationID, productIDs); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, product_id, available
+		FROM warehouse_products
+		WHERE warehouse_id = $1 AND product_id = ANY($2)
+		ORDER BY id
+		FOR UPDATE`,
+		sourceID, pq.Array(productIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error to lock warehouse products: %w", err)
+	}
+	defer rows.Close()
+
+	available := make(map[int]models.WarehouseProduct, len(quantities))
+	for rows.Next() {
+		var wh models.WarehouseProduct
+		if err := rows.Scan(&wh.ID, &wh.ProductID, &wh.Quantity); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		available[wh.ProductID] = wh
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	movements := make([]stockMovement, 0, len(quantities))
+	for productID, quantity := range quantities {
+		wh, ok := available[productID]
+		if !ok || wh.Quantity < quantity {
+			return nil, fmt.Errorf("not enough quantity of product %d in warehouse %d: %w", productID, sourceID, models.ErrInsufficientStock)
+		}
+		movements = append(movements, stockMovement{warehouseProductID: wh.ID, kind: models.MovementTransfer, onHand: -quantity})
+	}
+	return movements, nil
+}
+
+// receiveTransfer returns the movements which add quantities to the stock of the destination warehouse.
+// It fails with models.ErrNotFound if the warehouse is deleted.
+func receiveTransfer(ctx context.Context, tx *sql.Tx, destinationID int, quantities map[int]int) ([]stockMovement, error) {
+	if err := lockWarehouses(ctx, tx, destinationID); err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]int, 0, len(quantities))
+	for productID := range quantities {
+		productIDs = append(productIDs, productID)
+	}
+
+	warehouseProductIDs, err := createWarehouseProducts(ctx, tx, destinationID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	movements := make([]stockMovement, 0, len(quantities))
+	for productID, quantity := range quantities {
+		movements = append(movements, stockMovement{warehouseProductID: warehouseProductIDs[productID], kind: models.MovementTransfer, onHand: quantity})
+	}
+	return movements, nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/lib/pq"
+)
+
+func (r *Repository) Warehouses(ctx context.Context) ([]models.Warehouse, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		"select id, title, available, lat, lng from warehouses where deleted_at is null order by id")
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var warehouses []models.Warehouse
+	for rows.Next() {
+		var warehouse models.Warehouse
+		if err := rows.Scan(
+			&warehouse.ID,
+			&warehouse.Title,
+			&warehouse.Available,
+			&warehouse.Latitude,
+			&warehouse.Longitude,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return warehouses, nil
+}
+
+// NearestWarehouses returns up to limit available warehouses nearest to the point (lat, long) with their
+// stock of productIDs. If productIDs are set, only warehouses which have any of them in stock are returned.
+func (r *Repository) NearestWarehouses(ctx context.Context, lat, long float64, productIDs []int, limit int) ([]models.NearestWarehouse, error) {
+	rows, err := r.db.QueryContext(
+		ctx,
+		`SELECT
+			w.id,
+			w.title,
+			w.available,
+			w.lat,
+			w.lng,
+			`+warehouseDistance+` AS distance,
+			s.part_numbers,
+			s.quantities
+		FROM warehouses w
+		CROSS JOIN LATERAL (
+			SELECT
+				array_agg(p.part_number ORDER BY p.part_number),
+				array_agg(wp.available ORDER BY p.part_number)
+			FROM warehouse_products wp
+			JOIN products p ON wp.product_id = p.id
+			WHERE wp.warehouse_id = w.id AND wp.product_id = ANY($3) AND wp.available > 0
+		) s (part_numbers, quantities)
+		WHERE w.available = true AND w.deleted_at IS NULL
+			AND (cardinality($3::integer[]) = 0 OR s.part_numbers IS NOT NULL)
+		ORDER BY w.location <-> `+requestPoint+`, w.id
+		LIMIT $4`,
+		lat, long, pq.Array(productIDs), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var warehouses []models.NearestWarehouse
+	for rows.Next() {
+		var (
+			warehouse   models.NearestWarehouse
+			partNumbers pq.StringArray
+			quantities  pq.Int64Array
+		)
+		if err := rows.Scan(
+			&warehouse.ID,
+			&warehouse.Title,
+			&warehouse.Available,
+			&warehouse.Latitude,
+			&warehouse.Longitude,
+			&warehouse.Distance,
+			&partNumbers,
+			&quantities,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+
+		for i, partNumber := range partNumbers {
+			warehouse.Stock = append(warehouse.Stock, models.WarehouseStock{PartNumber: partNumber, Quantity: int(quantities[i])})
+		}
+		warehouses = append(warehouses, warehouse)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return warehouses, nil
+}
+
+func (r *Repository) WarehouseByID(ctx context.Context, warehouseID int) (models.Warehouse, error) {
+	warehouse := models.Warehouse{ID: warehouseID}
+	err := r.db.QueryRowContext(
+		ctx,
+		"select title, available, lat, lng from warehouses where id = $1 and deleted_at is null",
+		warehouseID,
+	).Scan(&warehouse.Title, &warehouse.Available, &warehouse.Latitude, &warehouse.Longitude)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Warehouse{}, models.ErrNotFound.WithDetail("warehouse %d", warehouseID)
+	}
+	if err != nil {
+		return models.Warehouse{}, fmt.Errorf("query error: %w", err)
+	}
+	return warehouse, nil
+}
+
+func (r *Repository) CreateWarehouse(ctx context.Context, warehouse models.Warehouse) (models.Warehouse, error) {
+	if err := r.db.QueryRowContext(
+		ctx,
+		"insert into warehouses (title, available, lat, lng) values ($1, $2, $3, $4) returning id",
+		warehouse.Title, warehouse.Available, warehouse.Latitude, warehouse.Longitude,
+	).Scan(&warehouse.ID); err != nil {
+		return models.Warehouse{}, fmt.Errorf("error to create warehouse: %w", err)
+	}
+	return warehouse, nil
+}
+
+func (r *Repository) UpdateWarehouse(ctx context.Context, warehouseID int, req models.UpdateWarehouseRequest) (models.Warehouse, error) {
+	warehouse := models.Warehouse{ID: warehouseID}
+	err := r.db.QueryRowContext(
+		ctx,
+		`UPDATE warehouses
+		SET
+			title = COALESCE($2, title),
+			available = COALESCE($3, available),
+			lat = COALESCE($4, lat),
+			lng = COALESCE($5, lng)
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING title, available, lat, lng`,
+		warehouseID, req.Title, req.Available, req.Latitude, req.Longitude,
+	).Scan(&warehouse.Title, &warehouse.Available, &warehouse.Latitude, &warehouse.Longitude)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Warehouse{}, models.ErrNotFound.WithDetail("warehouse %d", warehouseID)
+	}
+	if err != nil {
+		return models.Warehouse{}, fmt.Errorf("error to update warehouse: %w", err)
+	}
+	return warehouse, nil
+}
+
+// DeleteWarehouse marks the warehouse as deleted and unavailable. It refuses with models.ErrConflict
+// while the warehouse has reserved or confirmed products or transfers in transit to it.
+func (r *Repository) DeleteWarehouse(ctx context.Context, warehouseID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error to begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// waits for reservations which have locked the warehouse in lockWarehouseProducts
+	var id int
+	err = tx.QueryRowContext(
+		ctx,
+		"select id from warehouses where id = $1 and deleted_at is null for update",
+		warehouseID,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ErrNotFound.WithDetail("warehouse %d", warehouseID)
+	}
+	if err != nil {
+		return fmt.Errorf("error to lock warehouse: %w", err)
+	}
+
+	var active int
+	if err = tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*)
+		FROM reserved_products rp
+		JOIN warehouse_products wp ON rp.warehouse_product_id = wp.id
+		WHERE wp.warehouse_id = $1 AND rp.status = ANY($2)`,
+		warehouseID, pq.Array([]int{int(models.StatusReserved), int(models.StatusConfirmed)}),
+	).Scan(&active); err != nil {
+		return fmt.Errorf("error to count active reservations: %w", err)
+	}
+
+	if active > 0 {
+		return models.ErrConflict.WithDetail("warehouse %d has %d active reserved products", warehouseID, active)
+	}
+
+	var inTransit int
+	if err = tx.QueryRowContext(
+		ctx,
+		"select count(*) from transfers where destination_warehouse_id = $1 and status = $2",
+		warehouseID, models.TransferInTransit,
+	).Scan(&inTransit); err != nil {
+		return fmt.Errorf("error to count transfers in transit: %w", err)
+	}
+
+	if inTransit > 0 {
+		return models.ErrConflict.WithDetail("warehouse %d has %d transfers in transit to it", warehouseID, inTransit)
+	}
+
+	if _, err = tx.ExecContext(
+		ctx,
+		"update warehouses set deleted_at = NOW(), available = false where id = $1",
+		warehouseID,
+	); err != nil {
+		return fmt.Errorf("error to delete warehouse: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error to commit tx: %w", err)
+	}
+	return nil
+}
+
+// lockWarehouses locks the warehouses for share, so they can not be deleted until the transaction ends.
+// It fails with models.ErrNotFound if any of them does not exist or is deleted.
+func lockWarehouses(ctx context.Context, tx *sql.Tx, warehouseIDs ...int) error {
+	rows, err := tx.QueryContext(
+		ctx,
+		"select id from warehouses where id = ANY($1) and deleted_at is null order by id for share",
+		pq.Array(warehouseIDs))
+	if err != nil {
+		return fmt.Errorf("error to lock warehouses: %w", err)
+	}
+	defer rows.Close()
+
+	locked := make(map[int]bool, len(warehouseIDs))
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("scan error: %w", err)
+		}
+		locked[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+
+	var missing []string
+	for _, id := range warehouseIDs {
+		if !locked[id] {
+			missing = append(missing, fmt.Sprint(id))
+		}
+	}
+	if len(missing) > 0 {
+		return models.ErrNotFound.WithDetail("warehouse %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
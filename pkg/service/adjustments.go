@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+)
+
+// CreateAdjustment sets the stock on hand of the warehouse to the counted quantities and returns
+// the variance report. Counts of items with the same part number are summed up, as the products
+// may be counted in several places of the warehouse.
+func (s *Service) CreateAdjustment(ctx context.Context, warehouseID int, req models.CreateAdjustmentRequest) (models.Adjustment, error) {
+	items := make([]models.StockItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.StockItem{PartNumber: item.PartNumber, Quantity: *item.Counted}
+	}
+
+	_, counted, err := s.stockQuantities(ctx, items)
+	if err != nil {
+		return models.Adjustment{}, err
+	}
+
+	adjustment, err := s.repos.CreateAdjustment(ctx, warehouseID, req.Reason, counted)
+	if err != nil {
+		return models.Adjustment{}, fmt.Errorf("error to create adjustment: %w", err)
+	}
+	return adjustment, nil
+}
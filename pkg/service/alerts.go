@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// lowStockNotifyTimeout limits the time of notifying low stock alerts.
+	lowStockNotifyTimeout = 30 * time.Second
+	// lowStockNotifyLease is how long claimed alerts are not claimed by other evaluators,
+	// it outlasts the notification, so only the claims of crashed processes expire.
+	lowStockNotifyLease = 2 * lowStockNotifyTimeout
+)
+
+// notifier delivers the opened low stock alerts, e.g. to the log or a webhook.
+type notifier interface {
+	NotifyLowStock(ctx context.Context, alerts []models.LowStockAlert) error
+}
+
+// SetReorderPoints sets the reorder points of products in the warehouse and returns all its reorder points.
+// Items with the same part number are merged, the last one wins.
+func (s *Service) SetReorderPoints(ctx context.Context, warehouseID int, req models.SetReorderPointsRequest) ([]models.ReorderPoint, error) {
+	partNumbers := make([]string, len(req.Items))
+	for i, item := range req.Items {
+		partNumbers[i] = item.PartNumber
+	}
+
+	productIDs, err := s.productIDs(ctx, partNumbers)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make(map[int]int, len(req.Items))
+	for _, item := range req.Items {
+		points[productIDs[item.PartNumber]] = *item.ReorderPoint
+	}
+
+	if err = s.repos.SetReorderPoints(ctx, warehouseID, points); err != nil {
+		return nil, fmt.Errorf("error to set reorder points: %w", err)
+	}
+	return s.ReorderPoints(ctx, warehouseID)
+}
+
+func (s *Service) ReorderPoints(ctx context.Context, warehouseID int) ([]models.ReorderPoint, error) {
+	if _, err := s.repos.WarehouseByID(ctx, warehouseID); err != nil {
+		return nil, fmt.Errorf("error to get warehouse: %w", err)
+	}
+
+	points, err := s.repos.ReorderPoints(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("error to get reorder points: %w", err)
+	}
+	return points, nil
+}
+
+func (s *Service) LowStockAlerts(ctx context.Context, req models.LowStockAlertsRequest) ([]models.LowStockAlert, error) {
+	var warehouseID int
+	if req.WarehouseID != nil {
+		warehouseID = *req.WarehouseID
+	}
+
+	alerts, err := s.repos.LowStockAlerts(ctx, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("error to get low stock alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// RunLowStockEvaluation evaluates the stock against reorder points every interval until ctx is done.
+func (s *Service) RunLowStockEvaluation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.EvaluateLowStock(ctx); err != nil {
+				log.Errorf("error to evaluate low stock: %v", err)
+			}
+		}
+	}
+}
+
+// EvaluateLowStock opens an alert for every product whose available stock in a warehouse is below its reorder point
+// and resolves the alerts of replenished products. Every alert is notified once, so the stock which stays below
+// its reorder point is not notified again until it is replenished. Alerts are notified after the evaluation
+// is committed, so a slow notifier does not hold it; alerts which failed to be notified are notified by
+// the next evaluation.
+func (s *Service) EvaluateLowStock(ctx context.Context) error {
+	if err := s.repos.UpdateLowStockAlerts(ctx); err != nil {
+		return fmt.Errorf("error to update low stock alerts: %w", err)
+	}
+
+	alerts, err := s.repos.ClaimUnnotifiedLowStockAlerts(ctx, lowStockNotifyLease)
+	if err != nil {
+		return fmt.Errorf("error to claim low stock alerts: %w", err)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(alerts))
+	for i, alert := range alerts {
+		ids[i] = alert.ID
+	}
+
+	if s.notifier != nil {
+		log.Infof("notifying %d low stock alerts", len(alerts))
+
+		notifyCtx, cancel := context.WithTimeout(ctx, lowStockNotifyTimeout)
+		err = s.notifier.NotifyLowStock(notifyCtx, alerts)
+		cancel()
+		if err != nil {
+			if releaseErr := s.repos.ReleaseLowStockAlerts(context.WithoutCancel(ctx), ids); releaseErr != nil {
+				log.Errorf("error to release low stock alerts: %v", releaseErr)
+			}
+			return fmt.Errorf("error to notify low stock alerts: %w", err)
+		}
+	}
+
+	if err = s.repos.SetLowStockAlertsNotified(ctx, ids); err != nil {
+		return fmt.Errorf("error to mark low stock alerts notified: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mockrepository "github.com/Hymiside/lamoda-api/mock/repository"
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/Hymiside/lamoda-api/pkg/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var lowStockAlerts = []models.LowStockAlert{
+	{ID: 1, WarehouseID: 1, ProductID: 2, PartNumber: "P13579", ReorderPoint: 30, Available: 23},
+	{ID: 2, WarehouseID: 2, ProductID: 3, PartNumber: "P97431", ReorderPoint: 10, Available: 5},
+}
+
+type notifierFunc func(ctx context.Context, alerts []models.LowStockAlert) error
+
+func (f notifierFunc) NotifyLowStock(ctx context.Context, alerts []models.LowStockAlert) error {
+	return f(ctx, alerts)
+}
+
+func TestService_EvaluateLowStock(t *testing.T) {
+	tests := []struct {
+		name      string
+		notifyErr error
+		mockRepo  func(repo *mockrepository.RepositoryMock)
+		wantErr   error
+	}{
+		{
+			name: "notified",
+			mockRepo: func(repo *mockrepository.RepositoryMock) {
+				repo.On("SetLowStockAlertsNotified", mock.Anything, []int{1, 2}).Return(nil).Once()
+			},
+		},
+		{
+			name:      "notify failed",
+			notifyErr: errors.New("webhook responded with status 502"),
+			mockRepo: func(repo *mockrepository.RepositoryMock) {
+				repo.On("ReleaseLowStockAlerts", mock.Anything, []int{1, 2}).Return(nil).Once()
+			},
+			wantErr: errors.New("webhook responded with status 502"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockrepository.RepositoryMock)
+			repo.On("UpdateLowStockAlerts", mock.Anything).Return(nil).Once()
+			repo.On("ClaimUnnotifiedLowStockAlerts", mock.Anything, mock.Anything).Return(lowStockAlerts, nil).Once()
+			tt.mockRepo(repo)
+
+			var notified []models.LowStockAlert
+			s := service.NewService(repo, models.ConfigReservation{}, models.ConfigIdempotency{},
+				notifierFunc(func(ctx context.Context, alerts []models.LowStockAlert) error {
+					// the notification is limited in time, and the alerts are committed before it
+					_, ok := ctx.Deadline()
+					assert.True(t, ok)
+					repo.AssertCalled(t, "UpdateLowStockAlerts", mock.Anything)
+
+					notified = alerts
+					return tt.notifyErr
+				}))
+
+			err := s.EvaluateLowStock(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorContains(t, err, tt.wantErr.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, lowStockAlerts, notified)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestService_EvaluateLowStockNothingToNotify(t *testing.T) {
+	repo := new(mockrepository.RepositoryMock)
+	repo.On("UpdateLowStockAlerts", mock.Anything).Return(nil).Once()
+	repo.On("ClaimUnnotifiedLowStockAlerts", mock.Anything, mock.Anything).Return(nil, nil).Once()
+
+	s := service.NewService(repo, models.ConfigReservation{}, models.ConfigIdempotency{},
+		notifierFunc(func(ctx context.Context, alerts []models.LowStockAlert) error {
+			t.Fatal("nothing should be notified")
+			return nil
+		}))
+
+	require.NoError(t, s.EvaluateLowStock(context.Background()))
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+)
+
+// maxExactBasketWarehouses limits the number of warehouses among which the fewest warehouses
+// of a basket are searched exactly. With more warehouses they are searched greedily.
+const maxExactBasketWarehouses = 12
+
+// maxExactCostWarehouses limits the number of warehouses of one product among which the lowest cost
+// allocation is searched exactly. With more warehouses it is searched greedily.
+const maxExactCostWarehouses = 12
+
+// Allocator chooses the stock to reserve products from.
+type Allocator interface {
+	// Allocate chooses stock for quantities of products by their ids. Stock is the stock of the products
+	// in available warehouses ordered by the distance. Only products which can be allocated in full
+	// are returned.
+	Allocate(quantities map[int]int, stock []models.WarehouseProduct) map[int][]models.ReservationProducts
+}
+
+func NewAllocator(strategy models.AllocationStrategy, cfg models.ConfigReservation) (Allocator, error) {
+	switch strategy {
+	case models.StrategyNearest:
+		return productAllocator(allocateNearest), nil
+	case models.StrategyFewestWarehouses:
+		extraDistance := math.Inf(1)
+		if cfg.ExtraDistance != nil {
+			extraDistance = *cfg.ExtraDistance
+		}
+		return fewestWarehousesAllocator{extraDistance: extraDistance}, nil
+	case models.StrategyLowestCost:
+		return productAllocator(lowestCost(cfg.ShipmentCost, cfg.UnitCostPerKm)), nil
+	case models.StrategyBalanceStock:
+		return productAllocator(allocateBalanceStock), nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q", strategy)
+	}
+}
+
+// productAllocator allocates every product independently of the others. It returns false
+// if quantity can not be allocated in full from the stock of the product.
+type productAllocator func(quantity int, stock []models.WarehouseProduct) ([]models.ReservationProducts, bool)
+
+func (a productAllocator) Allocate(quantities map[int]int, stock []models.WarehouseProduct) map[int][]models.ReservationProducts {
+	stockByProduct := make(map[int][]models.WarehouseProduct, len(quantities))
+	for _, wh := range stock {
+		stockByProduct[wh.ProductID] = append(stockByProduct[wh.ProductID], wh)
+	}
+
+	allocation := make(map[int][]models.ReservationProducts, len(quantities))
+	for productID, quantity := range quantities {
+		if lines, ok := a(quantity, stockByProduct[productID]); ok {
+			allocation[productID] = lines
+		}
+	}
+	return allocation
+}
+
+func reservationLine(wh models.WarehouseProduct, quantity int) models.ReservationProducts {
+	return models.ReservationProducts{
+		WarehouseProductID: wh.ID,
+		ProductID:          wh.ProductID,
+		WarehouseID:        wh.WarehouseID,
+		Quantity:           quantity,
+		Distance:           wh.Distance,
+	}
+}
+
+// allocateNearest splits quantity across the stock in its order, taking as much as possible
+// from the first warehouses.
+func allocateNearest(quantity int, stock []models.WarehouseProduct) ([]models.ReservationProducts, bool) {
+	var lines []models.ReservationProducts
+	for _, wh := range stock {
+		if quantity == 0 {
+			break
+		}
+
+		take := min(quantity, wh.Quantity)
+		if take <= 0 {
+			continue
+		}
+		lines = append(lines, reservationLine(wh, take))
+		quantity -= take
+	}
+	return lines, quantity == 0
+}
+
+// lowestCost returns an allocator which minimises the cost of shipments: every warehouse costs
+// shipmentCost plus unitCostPerKm for every unit and kilometer.
+func lowestCost(shipmentCost, unitCostPerKm float64) productAllocator {
+	cost := func(lines []models.ReservationProducts) float64 {
+		var cost float64
+		for _, line := range lines {
+			cost += shipmentCost + unitCostPerKm*line.Distance/1000*float64(line.Quantity)
+		}
+		return cost
+	}
+
+	return func(quantity int, stock []models.WarehouseProduct) ([]models.ReservationProducts, bool) {
+		if len(stock) > maxExactCostWarehouses {
+			return allocateLowestCostGreedy(quantity, stock, shipmentCost, unitCostPerKm)
+		}
+
+		// units are cheaper from nearer warehouses, so every set of warehouses is filled from the nearest
+		// one; sets with a warehouse which is not needed are covered by their subsets
+		var (
+			best     []models.ReservationProducts
+			bestCost = math.Inf(1)
+		)
+		for set := 1; set < 1<<len(stock); set++ {
+			var chosen []models.WarehouseProduct
+			for i, wh := range stock {
+				if set&(1<<i) != 0 {
+					chosen = append(chosen, wh)
+				}
+			}
+
+			lines, ok := allocateNearest(quantity, chosen)
+			if !ok || len(lines) != len(chosen) {
+				continue
+			}
+			if c := cost(lines); c < bestCost {
+				best, bestCost = lines, c
+			}
+		}
+		return best, best != nil
+	}
+}
+
+// allocateLowestCostGreedy takes quantity from the warehouse with the lowest cost per unit taken
+// until quantity is allocated. The lines are ordered as the stock.
+func allocateLowestCostGreedy(quantity int, stock []models.WarehouseProduct, shipmentCost, unitCostPerKm float64) ([]models.ReservationProducts, bool) {
+	taken := make([]int, len(stock))
+	for quantity > 0 {
+		best, bestCost := -1, math.Inf(1)
+		for i, wh := range stock {
+			take := min(quantity, wh.Quantity)
+			if taken[i] > 0 || take <= 0 {
+				continue
+			}
+			if c := (shipmentCost + unitCostPerKm*wh.Distance/1000*float64(take)) / float64(take); c < bestCost {
+				best, bestCost = i, c
+			}
+		}
+		if best < 0 {
+			return nil, false
+		}
+
+		taken[best] = min(quantity, stock[best].Quantity)
+		quantity -= taken[best]
+	}
+
+	var lines []models.ReservationProducts
+	for i, wh := range stock {
+		if taken[i] > 0 {
+			lines = append(lines, reservationLine(wh, taken[i]))
+		}
+	}
+	return lines, true
+}
+
+// allocateBalanceStock takes quantity from the warehouses with the most stock, so that their stock levels
+// are left as even as possible. Among warehouses with the same stock the nearest ones are taken from first.
+func allocateBalanceStock(quantity int, stock []models.WarehouseProduct) ([]models.ReservationProducts, bool) {
+	// above returns the quantity of stock above level
+	above := func(level int) int {
+		var total int
+		for _, wh := range stock {
+			total += max(wh.Quantity-level, 0)
+		}
+		return total
+	}
+
+	if above(0) < quantity {
+		return nil, false
+	}
+
+	// the lowest level at which there is less than quantity of stock above it; the stock above
+	// it is taken in full and the rest is taken one unit per warehouse which reaches the level
+	level := sort.Search(math.MaxInt32, func(level int) bool {
+		return above(level) < quantity
+	})
+	rest := quantity - above(level)
+
+	var lines []models.ReservationProducts
+	for _, wh := range stock {
+		take := max(wh.Quantity-level, 0)
+		if rest > 0 && wh.Quantity >= level {
+			take++
+			rest--
+		}
+		if take > 0 {
+			lines = append(lines, reservationLine(wh, take))
+		}
+	}
+	return lines, true
+}
+
+// fewestWarehousesAllocator reserves the whole basket from the fewest warehouses which are at most
+// extraDistance further than the farthest warehouse the nearest strategy would take products from.
+// Among sets of the same size the one with the least total distance is chosen, so a single nearest
+// warehouse which has the whole basket is preferred.
+type fewestWarehousesAllocator struct {
+	extraDistance float64
+}
+
+// basketWarehouse is the stock of a warehouse by product ids.
+type basketWarehouse struct {
+	distance float64
+	stock    map[int]models.WarehouseProduct
+}
+
+func (a fewestWarehousesAllocator) Allocate(quantities map[int]int, stock []models.WarehouseProduct) map[int][]models.ReservationProducts {
+	// products which can not be allocated from all warehouses can not be allocated from any set of them
+	nearest := productAllocator(allocateNearest).Allocate(quantities, stock)
+
+	var maxDistance float64
+	for _, lines := range nearest {
+		for _, line := range lines {
+			maxDistance = max(maxDistance, line.Distance)
+		}
+	}
+
+	var (
+		warehouses  []*basketWarehouse
+		byWarehouse = make(map[int]*basketWarehouse)
+	)
+	for _, wh := range stock {
+		if _, ok := nearest[wh.ProductID]; !ok || wh.Distance > maxDistance+a.extraDistance {
+			continue
+		}
+
+		w, ok := byWarehouse[wh.WarehouseID]
+		if !ok {
+			w = &basketWarehouse{distance: wh.Distance, stock: make(map[int]models.WarehouseProduct)}
+			byWarehouse[wh.WarehouseID] = w
+			warehouses = append(warehouses, w)
+		}
+		w.stock[wh.ProductID] = wh
+	}
+
+	required := make(map[int]int, len(nearest))
+	for productID := range nearest {
+		required[productID] = quantities[productID]
+	}
+
+	// the warehouses of the nearest allocation are within the distance, so there is always a set
+	var set []*basketWarehouse
+	if len(warehouses) <= maxExactBasketWarehouses {
+		set = fewestWarehousesExact(required, warehouses)
+	} else {
+		set = fewestWarehousesGreedy(required, warehouses)
+	}
+
+	allocation := make(map[int][]models.ReservationProducts, len(required))
+	for productID, quantity := range required {
+		var productStock []models.WarehouseProduct
+		for _, w := range set {
+			if wh, ok := w.stock[productID]; ok {
+				productStock = append(productStock, wh)
+			}
+		}
+		allocation[productID], _ = allocateNearest(quantity, productStock)
+	}
+	return allocation
+}
+
+// fewestWarehousesExact checks every set of warehouses. The set is ordered as warehouses.
+func fewestWarehousesExact(required map[int]int, warehouses []*basketWarehouse) []*basketWarehouse {
+	var (
+		best              []*basketWarehouse
+		bestSize          = math.MaxInt
+		bestTotalDistance = math.Inf(1)
+	)
+	for mask := 1; mask < 1<<len(warehouses); mask++ {
+		var (
+			set           []*basketWarehouse
+			totalDistance float64
+		)
+		for i, w := range warehouses {
+			if mask&(1<<i) != 0 {
+				set = append(set, w)
+				totalDistance += w.distance
+			}
+		}
+
+		if len(set) > bestSize || len(set) == bestSize && totalDistance >= bestTotalDistance || !covers(required, set) {
+			continue
+		}
+		best, bestSize, bestTotalDistance = set, len(set), totalDistance
+	}
+	return best
+}
+
+// fewestWarehousesGreedy adds the warehouse which has the most of the rest of the basket until the basket
+// is covered. Among warehouses with the same stock the nearest one is added. The set is ordered as warehouses.
+func fewestWarehousesGreedy(required map[int]int, warehouses []*basketWarehouse) []*basketWarehouse {
+	rest := make(map[int]int, len(required))
+	for productID, quantity := range required {
+		rest[productID] = quantity
+	}
+
+	chosen := make([]bool, len(warehouses))
+	for !covers(rest, nil) {
+		best, bestUnits := -1, 0
+		for i, w := range warehouses {
+			if chosen[i] {
+				continue
+			}
+
+			var units int
+			for productID, quantity := range rest {
+				units += min(quantity, w.stock[productID].Quantity)
+			}
+			if units > bestUnits {
+				best, bestUnits = i, units
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		chosen[best] = true
+		for productID, quantity := range rest {
+			rest[productID] = max(quantity-warehouses[best].stock[productID].Quantity, 0)
+		}
+	}
+
+	var set []*basketWarehouse
+	for i, w := range warehouses {
+		if chosen[i] {
+			set = append(set, w)
+		}
+	}
+	return set
+}
+
+// covers reports whether the warehouses have the required quantities of products.
+func covers(required map[int]int, warehouses []*basketWarehouse) bool {
+	for productID, quantity := range required {
+		for _, w := range warehouses {
+			quantity -= w.stock[productID].Quantity
+		}
+		if quantity > 0 {
+			return false
+		}
+	}
+	return true
+}
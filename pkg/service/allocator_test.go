@@ -0,0 +1,201 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/Hymiside/lamoda-api/pkg/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stockFixture is the stock of products 1, 2 and 3 in the warehouses 1, 2, 3 and 4,
+// which are 1, 2, 10 and 11 km away. Product 4 is out of stock.
+var stockFixture = []models.WarehouseProduct{
+	{ID: 1, ProductID: 1, WarehouseID: 1, Quantity: 3, Distance: 1000},
+	{ID: 2, ProductID: 2, WarehouseID: 1, Quantity: 1, Distance: 1000},
+	{ID: 3, ProductID: 3, WarehouseID: 1, Quantity: 1, Distance: 1000},
+	{ID: 4, ProductID: 1, WarehouseID: 2, Quantity: 10, Distance: 2000},
+	{ID: 5, ProductID: 3, WarehouseID: 2, Quantity: 1, Distance: 2000},
+	{ID: 6, ProductID: 3, WarehouseID: 3, Quantity: 4, Distance: 10000},
+	{ID: 7, ProductID: 1, WarehouseID: 4, Quantity: 6, Distance: 11000},
+	{ID: 8, ProductID: 2, WarehouseID: 4, Quantity: 4, Distance: 11000},
+	{ID: 9, ProductID: 3, WarehouseID: 4, Quantity: 10, Distance: 11000},
+}
+
+var quantitiesFixture = map[int]int{1: 8, 2: 2, 3: 5, 4: 1}
+
+// allocated maps products to the quantities taken from warehouses.
+type allocated map[int]map[int]int
+
+func allocate(t *testing.T, allocator service.Allocator, quantities map[int]int, stock []models.WarehouseProduct) allocated {
+	t.Helper()
+
+	got := make(allocated)
+	for productID, lines := range allocator.Allocate(quantities, stock) {
+		got[productID] = make(map[int]int)
+		for _, line := range lines {
+			assert.Equal(t, productID, line.ProductID)
+			got[productID][line.WarehouseID] += line.Quantity
+		}
+	}
+	return got
+}
+
+func TestAllocator(t *testing.T) {
+	tests := []struct {
+		strategy models.AllocationStrategy
+		want     allocated
+	}{
+		{
+			strategy: models.StrategyNearest,
+			want: allocated{
+				1: {1: 3, 2: 5},
+				2: {1: 1, 4: 1},
+				3: {1: 1, 2: 1, 3: 3},
+			},
+		},
+		{
+			// no warehouse has the whole basket, the warehouses 1 and 4 are the nearest pair which has it
+			strategy: models.StrategyFewestWarehouses,
+			want: allocated{
+				1: {1: 3, 4: 5},
+				2: {1: 1, 4: 1},
+				3: {1: 1, 4: 4},
+			},
+		},
+		{
+			// a warehouse costs 12 and a unit costs 1 per km
+			strategy: models.StrategyLowestCost,
+			want: allocated{
+				1: {2: 8},
+				2: {4: 2},
+				3: {1: 1, 3: 4},
+			},
+		},
+		{
+			strategy: models.StrategyBalanceStock,
+			want: allocated{
+				1: {2: 6, 4: 2},
+				2: {4: 2},
+				3: {4: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			allocator, err := service.NewAllocator(tt.strategy, models.ConfigReservation{ShipmentCost: 12, UnitCostPerKm: 1})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, allocate(t, allocator, quantitiesFixture, stockFixture))
+		})
+	}
+}
+
+func TestAllocator_insufficientStock(t *testing.T) {
+	for _, strategy := range []models.AllocationStrategy{
+		models.StrategyNearest,
+		models.StrategyFewestWarehouses,
+		models.StrategyLowestCost,
+		models.StrategyBalanceStock,
+	} {
+		t.Run(string(strategy), func(t *testing.T) {
+			allocator, err := service.NewAllocator(strategy, models.ConfigReservation{ShipmentCost: 12, UnitCostPerKm: 1})
+			require.NoError(t, err)
+
+			// there are 19 of product 1 in total
+			assert.Empty(t, allocator.Allocate(map[int]int{1: 20}, stockFixture))
+		})
+	}
+}
+
+func TestAllocator_lowestCostGreedy(t *testing.T) {
+	// too many warehouses to search the lowest cost exactly
+	var stock []models.WarehouseProduct
+	for i := 1; i <= 20; i++ {
+		stock = append(stock, models.WarehouseProduct{ID: i, ProductID: 1, WarehouseID: i, Quantity: i, Distance: float64(i) * 1000})
+	}
+
+	allocator, err := service.NewAllocator(models.StrategyLowestCost, models.ConfigReservation{ShipmentCost: 100, UnitCostPerKm: 1})
+	require.NoError(t, err)
+
+	// the warehouse i costs 100/i + i per unit taken in full, which is the lowest for the warehouses 10
+	// and 11; the rest 4 units cost 25 + i per unit, which is the lowest for the warehouse 4
+	assert.Equal(t, allocated{1: {4: 4, 10: 10, 11: 11}}, allocate(t, allocator, map[int]int{1: 25}, stock))
+}
+
+func TestAllocator_fewestWarehousesBasket(t *testing.T) {
+	// the nearest warehouse 1 has all of product 1, but only the warehouse 2 has product 2 and it has the whole basket
+	stock := []models.WarehouseProduct{
+		{ID: 1, ProductID: 1, WarehouseID: 1, Quantity: 5, Distance: 1000},
+		{ID: 2, ProductID: 1, WarehouseID: 2, Quantity: 5, Distance: 2000},
+		{ID: 3, ProductID: 2, WarehouseID: 2, Quantity: 5, Distance: 2000},
+	}
+
+	allocator, err := service.NewAllocator(models.StrategyFewestWarehouses, models.ConfigReservation{})
+	require.NoError(t, err)
+
+	assert.Equal(t, allocated{1: {2: 2}, 2: {2: 2}}, allocate(t, allocator, map[int]int{1: 2, 2: 2}, stock))
+}
+
+func TestAllocator_fewestWarehousesExtraDistance(t *testing.T) {
+	// the nearest strategy takes products 1 and 3 from the warehouses 1 and 2, which are at most 2 km away,
+	// while the warehouse 4 which has the whole basket is 11 km away
+	quantities := map[int]int{1: 4, 3: 2}
+
+	tests := []struct {
+		name          string
+		extraDistance float64
+		want          allocated
+	}{
+		{
+			name:          "single warehouse within the distance",
+			extraDistance: 10000,
+			want:          allocated{1: {4: 4}, 3: {4: 2}},
+		},
+		{
+			name:          "single warehouse too far",
+			extraDistance: 5000,
+			want:          allocated{1: {1: 3, 2: 1}, 3: {1: 1, 2: 1}},
+		},
+		{
+			name:          "no extra distance",
+			extraDistance: 0,
+			want:          allocated{1: {1: 3, 2: 1}, 3: {1: 1, 2: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extraDistance := tt.extraDistance
+			allocator, err := service.NewAllocator(models.StrategyFewestWarehouses, models.ConfigReservation{ExtraDistance: &extraDistance})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, allocate(t, allocator, quantities, stockFixture))
+		})
+	}
+}
+
+func TestAllocator_fewestWarehousesGreedy(t *testing.T) {
+	// too many warehouses to search the fewest warehouses exactly: the warehouses 1-14 which are i km away
+	// have one unit of one product each, the warehouse 15 has the whole basket
+	var stock []models.WarehouseProduct
+	for i := 1; i <= 14; i++ {
+		stock = append(stock, models.WarehouseProduct{ID: i, ProductID: (i-1)%3 + 1, WarehouseID: i, Quantity: 1, Distance: float64(i) * 1000})
+	}
+	for productID := 1; productID <= 3; productID++ {
+		stock = append(stock, models.WarehouseProduct{ID: 14 + productID, ProductID: productID, WarehouseID: 15, Quantity: 5, Distance: 15000})
+	}
+
+	extraDistance := 10000.0
+	allocator, err := service.NewAllocator(models.StrategyFewestWarehouses, models.ConfigReservation{ExtraDistance: &extraDistance})
+	require.NoError(t, err)
+
+	assert.Equal(t, allocated{1: {15: 2}, 2: {15: 2}, 3: {15: 2}}, allocate(t, allocator, map[int]int{1: 2, 2: 2, 3: 2}, stock))
+}
+
+func TestNewAllocator_unknownStrategy(t *testing.T) {
+	_, err := service.NewAllocator("random", models.ConfigReservation{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+)
+
+// StockMovements returns a page of the stock movements of the warehouse in the order they were made.
+// The next page is requested with the returned NextCursor, which is empty on the last page.
+func (s *Service) StockMovements(ctx context.Context, warehouseID int, req models.StockMovementsRequest) (models.StockMovementsPage, error) {
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return models.StockMovementsPage{}, &models.ValidationError{Fields: []models.FieldError{{
+			Field:   "to",
+			Rule:    "gtfield",
+			Message: "to must be after from",
+		}}}
+	}
+
+	filter := models.StockMovementsFilter{
+		WarehouseID: warehouseID,
+		From:        req.From,
+		To:          req.To,
+		// one more movement tells whether there is the next page
+		Limit: req.Limit + 1,
+	}
+
+	if req.Cursor != "" {
+		after, err := strconv.ParseInt(req.Cursor, 10, 64)
+		if err != nil || after < 0 {
+			return models.StockMovementsPage{}, &models.ValidationError{Fields: []models.FieldError{{
+				Field:   "cursor",
+				Rule:    "cursor",
+				Message: "cursor is invalid",
+			}}}
+		}
+		filter.After = after
+	}
+
+	movements, err := s.repos.StockMovements(ctx, filter)
+	if err != nil {
+		return models.StockMovementsPage{}, fmt.Errorf("error to get stock movements: %w", err)
+	}
+
+	page := models.StockMovementsPage{Movements: movements}
+	if len(movements) > req.Limit {
+		page.Movements = movements[:req.Limit]
+		page.NextCursor = strconv.FormatInt(page.Movements[req.Limit-1].ID, 10)
+	}
+	return page, nil
+}
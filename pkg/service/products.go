@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+)
+
+// Products returns a page of products. The next page is requested with the returned NextCursor,
+// which is empty on the last page.
+func (s *Service) Products(ctx context.Context, req models.ProductsRequest) (models.ProductsPage, error) {
+	sortField := strings.TrimPrefix(req.Sort, "-")
+	if sortField == "" {
+		sortField = "id"
+	}
+
+	filter := models.ProductsFilter{
+		PartNumberPrefix: req.PartNumber,
+		Title:            req.Title,
+		MinWidth:         req.MinWidth,
+		MaxWidth:         req.MaxWidth,
+		MinHeight:        req.MinHeight,
+		MaxHeight:        req.MaxHeight,
+		MinDepth:         req.MinDepth,
+		MaxDepth:         req.MaxDepth,
+		SortField:        sortField,
+		Descending:       strings.HasPrefix(req.Sort, "-"),
+		// one more product tells whether there is the next page
+		Limit: req.Limit + 1,
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeProductsCursor(req.Cursor)
+		if err != nil || cursor.Sort != req.Sort {
+			return models.ProductsPage{}, &models.ValidationError{Fields: []models.FieldError{{
+				Field:   "cursor",
+				Rule:    "cursor",
+				Message: "cursor is invalid or was issued for another sort",
+			}}}
+		}
+		filter.After = &cursor
+	}
+
+	products, err := s.repos.Products(ctx, filter)
+	if err != nil {
+		return models.ProductsPage{}, fmt.Errorf("error to get products: %w", err)
+	}
+
+	page := models.ProductsPage{Products: products}
+	if len(products) > req.Limit {
+		page.Products = products[:req.Limit]
+		page.NextCursor = encodeProductsCursor(productsCursor(req.Sort, sortField, page.Products[req.Limit-1]))
+	}
+	return page, nil
+}
+
+func productsCursor(sort, sortField string, product models.Product) models.ProductsCursor {
+	cursor := models.ProductsCursor{Sort: sort, ID: product.ID}
+	switch sortField {
+	case "part_number":
+		cursor.Value = product.PartNumber
+	case "title":
+		cursor.Value = product.Title
+	case "width":
+		cursor.Value = strconv.Itoa(product.Width)
+	case "height":
+		cursor.Value = strconv.Itoa(product.Height)
+	case "depth":
+		cursor.Value = strconv.Itoa(product.Depth)
+	}
+	return cursor
+}
+
+func encodeProductsCursor(cursor models.ProductsCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeProductsCursor(s string) (models.ProductsCursor, error) {
+	var cursor models.ProductsCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, err
+	}
+	if err = json.Unmarshal(b, &cursor); err != nil {
+		return cursor, err
+	}
+
+	switch strings.TrimPrefix(cursor.Sort, "-") {
+	case "width", "height", "depth":
+		_, err = strconv.Atoi(cursor.Value)
+	}
+	return cursor, err
+}
+
+func (s *Service) Product(ctx context.Context, partNumber string) (models.Product, error) {
+	product, err := s.repos.ProductByPartNumber(ctx, partNumber)
+	if err != nil {
+		return models.Product{}, fmt.Errorf("error to get product: %w", err)
+	}
+	return product, nil
+}
+
+// ProductAvailability returns the stock of the product in every warehouse and in the whole network.
+func (s *Service) ProductAvailability(ctx context.Context, partNumber string) (models.ProductAvailability, error) {
+	product, err := s.repos.ProductByPartNumber(ctx, partNumber)
+	if err != nil {
+		return models.ProductAvailability{}, fmt.Errorf("error to get product: %w", err)
+	}
+
+	availability, err := s.repos.AvailabilityByProductID(ctx, product.ID)
+	if err != nil {
+		return models.ProductAvailability{}, fmt.Errorf("error to get availability of product: %w", err)
+	}
+	availability.Product = product
+	return availability, nil
+}
+
+func (s *Service) CreateProduct(ctx context.Context, req models.CreateProductRequest) (models.Product, error) {
+	product, err := s.repos.CreateProduct(ctx, models.Product{
+		PartNumber: req.PartNumber,
+		Title:      req.Title,
+		Width:      req.Width,
+		Height:     req.Height,
+		Depth:      req.Depth,
+	})
+	if err != nil {
+		return models.Product{}, fmt.Errorf("error to create product: %w", err)
+	}
+	return product, nil
+}
+
+func (s *Service) UpdateProduct(ctx context.Context, partNumber string, req models.UpdateProductRequest) (models.Product, error) {
+	product, err := s.repos.UpdateProduct(ctx, partNumber, req)
+	if err != nil {
+		return models.Product{}, fmt.Errorf("error to update product: %w", err)
+	}
+	return product, nil
+}
+
+func (s *Service) ArchiveProduct(ctx context.Context, partNumber string) error {
+	if err := s.repos.ArchiveProduct(ctx, partNumber); err != nil {
+		return fmt.Errorf("error to archive product: %w", err)
+	}
+	return nil
+}
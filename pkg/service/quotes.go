@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// quote is the signed content of a quote token.
+type quote struct {
+	ID        uuid.UUID             `json:"id"`
+	ExpiresAt time.Time             `json:"expires_at"`
+	Latitude  float64               `json:"latitude"`
+	Longitude float64               `json:"longitude"`
+	HoldTTL   int                   `json:"hold_ttl,omitempty"`
+	Lines     []quoteLine           `json:"lines"`
+	Items     []models.ReservedItem `json:"items"`
+}
+
+// quoteLine is a line of the plan with the quantity of the stock it was planned on.
+type quoteLine struct {
+	models.ReservationProducts
+	Stock int `json:"stock"`
+}
+
+// ReservationQuote runs the allocation of ReservationProducts on the current stock without reserving it.
+// If anything can be reserved under the requested policy, the plan is returned with a token to reserve it.
+func (s *Service) ReservationQuote(ctx context.Context, req models.ReservationProductsRequest) (models.ReservationQuote, error) {
+	rp, err := s.newReservationPlan(ctx, req)
+	if err != nil {
+		return models.ReservationQuote{}, err
+	}
+
+	var warehouses []models.WarehouseProduct
+	if len(rp.productIDs) > 0 {
+		if warehouses, err = s.repos.WarehousesByProductIDs(ctx, rp.productIDs, req.Latitude, req.Longitude); err != nil {
+			return models.ReservationQuote{}, fmt.Errorf("error to get warehouse products: %w", err)
+		}
+	}
+
+	lines, err := rp.plan(warehouses)
+	var allocationErr *models.AllocationError
+	if errors.As(err, &allocationErr) {
+		return models.ReservationQuote{Items: rp.items}, nil
+	}
+	if err != nil {
+		return models.ReservationQuote{}, err
+	}
+
+	stock := make(map[int]int, len(warehouses))
+	for _, wh := range warehouses {
+		stock[wh.ID] = wh.Quantity
+	}
+
+	q := quote{
+		ID:        uuid.New(),
+		ExpiresAt: time.Now().Add(s.cfg.QuoteTTL).UTC().Truncate(time.Second),
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		HoldTTL:   req.HoldTTL,
+		Lines:     make([]quoteLine, len(lines)),
+		Items:     rp.items,
+	}
+	for i, line := range lines {
+		q.Lines[i] = quoteLine{ReservationProducts: line, Stock: stock[line.WarehouseProductID]}
+	}
+
+	token, err := s.signQuote(q)
+	if err != nil {
+		return models.ReservationQuote{}, fmt.Errorf("error to sign quote: %w", err)
+	}
+
+	available := true
+	for _, item := range rp.items {
+		available = available && item.Status == models.ItemReserved
+	}
+	return models.ReservationQuote{QuoteToken: token, ExpiresAt: &q.ExpiresAt, Available: available, Items: rp.items}, nil
+}
+
+// RedeemReservationQuote reserves exactly the plan of the quote. It fails with models.ErrQuoteStale
+// if the quote is expired or the stock the plan is taken from has changed, and with models.ErrConflict
+// if the quote is already redeemed. A quote whose plan fails to be reserved can be redeemed again.
+func (s *Service) RedeemReservationQuote(ctx context.Context, token string) (models.ReservationProductsResponse, error) {
+	q, err := s.verifyQuote(token)
+	if err != nil {
+		return models.ReservationProductsResponse{}, &models.ValidationError{Fields: []models.FieldError{{
+			Field:   "quote_token",
+			Rule:    "quote",
+			Message: fmt.Sprintf("invalid quote_token: %v", err),
+		}}}
+	}
+
+	if time.Now().After(q.ExpiresAt) {
+		return models.ReservationProductsResponse{}, models.ErrQuoteStale.WithDetail("quote expired at %s", q.ExpiresAt.Format(time.RFC3339))
+	}
+
+	redeemed, err := s.repos.RedeemQuote(ctx, q.ID)
+	if err != nil {
+		return models.ReservationProductsResponse{}, fmt.Errorf("error to redeem quote: %w", err)
+	}
+	if !redeemed {
+		return models.ReservationProductsResponse{}, models.ErrConflict.WithDetail("quote is already redeemed")
+	}
+
+	var productIDs []int
+	for _, line := range q.Lines {
+		productIDs = append(productIDs, line.ProductID)
+	}
+
+	plan := func(warehouses []models.WarehouseProduct) ([]models.ReservationProducts, error) {
+		stock := make(map[int]int, len(warehouses))
+		for _, wh := range warehouses {
+			stock[wh.ID] = wh.Quantity
+		}
+
+		lines := make([]models.ReservationProducts, len(q.Lines))
+		for i, line := range q.Lines {
+			if quantity, ok := stock[line.WarehouseProductID]; !ok || quantity != line.Stock {
+				return nil, fmt.Errorf("stock of product %d in warehouse %d has changed: %w", line.ProductID, line.WarehouseID, models.ErrQuoteStale)
+			}
+			lines[i] = line.ReservationProducts
+		}
+		return lines, nil
+	}
+
+	reservationID := uuid.New()
+	expiresAt, err := s.repos.SetProductsToReserved(ctx, reservationID, s.holdTTL(q.HoldTTL), productIDs, q.Latitude, q.Longitude, plan)
+	if err != nil {
+		if deleteErr := s.repos.DeleteRedeemedQuote(context.WithoutCancel(ctx), q.ID); deleteErr != nil {
+			log.Errorf("error to delete redeemed quote %s: %v", q.ID, deleteErr)
+		}
+		return models.ReservationProductsResponse{}, fmt.Errorf("error to set products to reserved: %w", err)
+	}
+	return models.ReservationProductsResponse{ReservationID: reservationID, ExpiresAt: expiresAt, Items: q.Items}, nil
+}
+
+// purgeRedeemedQuotes deletes redeemed quotes whose tokens are expired.
+func (s *Service) purgeRedeemedQuotes(ctx context.Context) {
+	purged, err := s.repos.DeleteExpiredRedeemedQuotes(ctx, s.cfg.QuoteTTL)
+	if err != nil {
+		log.Errorf("error to purge redeemed quotes: %v", err)
+		return
+	}
+	if purged > 0 {
+		log.Infof("%d redeemed quotes purged", purged)
+	}
+}
+
+// signQuote encodes the quote as a token of its JSON and HMAC-SHA256 signature.
+func (s *Service) signQuote(q quote) (string, error) {
+	payload, err := json.Marshal(q)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, s.cfg.QuoteSecret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (s *Service) verifyQuote(token string) (quote, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return quote{}, errors.New("malformed token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return quote{}, errors.New("malformed token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return quote{}, errors.New("malformed token")
+	}
+
+	mac := hmac.New(sha256.New, s.cfg.QuoteSecret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return quote{}, errors.New("signature mismatch")
+	}
+
+	var q quote
+	if err = json.Unmarshal(payload, &q); err != nil || q.ID == uuid.Nil {
+		return quote{}, errors.New("malformed token")
+	}
+	return q, nil
+}
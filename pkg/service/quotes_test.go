@@ -0,0 +1,154 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mockrepository "github.com/Hymiside/lamoda-api/mock/repository"
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/Hymiside/lamoda-api/pkg/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// quoteRequest requests 3 of the product 2, which the warehouse 1 has 10 of in stockQuoted.
+var quoteRequest = models.ReservationProductsRequest{
+	Items:     []models.ReservationItem{{PartNumber: "P13579", Quantity: 3}},
+	Latitude:  58.0105,
+	Longitude: 56.2502,
+}
+
+var stockQuoted = []models.WarehouseProduct{{ID: 5, ProductID: 2, WarehouseID: 1, Quantity: 10, Distance: 1000}}
+
+// reserveWith runs the plan of SetProductsToReserved on stock as the repository would.
+func reserveWith(stock []models.WarehouseProduct, expiresAt time.Time) interface{} {
+	return func(
+		_ context.Context, _ uuid.UUID, _ time.Duration, _ []int, _, _ float64,
+		plan func(warehouses []models.WarehouseProduct) ([]models.ReservationProducts, error),
+	) (time.Time, error) {
+		if _, err := plan(stock); err != nil {
+			return time.Time{}, err
+		}
+		return expiresAt, nil
+	}
+}
+
+func newQuoteService(repo *mockrepository.RepositoryMock, quoteTTL time.Duration) *service.Service {
+	return service.NewService(repo, models.ConfigReservation{
+		TTL:         15 * time.Minute,
+		QuoteTTL:    quoteTTL,
+		QuoteSecret: []byte("secret"),
+	}, models.ConfigIdempotency{}, nil)
+}
+
+// quote returns a quote of quoteRequest on stockQuoted.
+func quote(t *testing.T, s *service.Service, repo *mockrepository.RepositoryMock) models.ReservationQuote {
+	t.Helper()
+
+	repo.On("ProductsByPartNumbers", mock.Anything, []string{"P13579"}).
+		Return([]models.Product{{ID: 2, PartNumber: "P13579"}}, nil).Once()
+	repo.On("WarehousesByProductIDs", mock.Anything, []int{2}, quoteRequest.Latitude, quoteRequest.Longitude).
+		Return(stockQuoted, nil).Once()
+
+	q, err := s.ReservationQuote(context.Background(), quoteRequest)
+	require.NoError(t, err)
+	require.NotEmpty(t, q.QuoteToken)
+	return q
+}
+
+func TestService_ReservationQuoteUnavailable(t *testing.T) {
+	repo := new(mockrepository.RepositoryMock)
+	s := newQuoteService(repo, time.Minute)
+
+	repo.On("ProductsByPartNumbers", mock.Anything, []string{"P13579"}).
+		Return([]models.Product{{ID: 2, PartNumber: "P13579"}}, nil)
+	repo.On("WarehousesByProductIDs", mock.Anything, []int{2}, quoteRequest.Latitude, quoteRequest.Longitude).
+		Return([]models.WarehouseProduct{{ID: 5, ProductID: 2, WarehouseID: 1, Quantity: 2, Distance: 1000}}, nil)
+
+	q, err := s.ReservationQuote(context.Background(), quoteRequest)
+	require.NoError(t, err)
+	assert.Empty(t, q.QuoteToken)
+	assert.Nil(t, q.ExpiresAt)
+	assert.False(t, q.Available)
+	assert.Equal(t, []models.ReservedItem{{PartNumber: "P13579", Quantity: 3, Status: models.ItemUnavailable}}, q.Items)
+	repo.AssertExpectations(t)
+}
+
+func TestService_RedeemReservationQuote(t *testing.T) {
+	repo := new(mockrepository.RepositoryMock)
+	s := newQuoteService(repo, time.Minute)
+	q := quote(t, s, repo)
+
+	expiresAt := time.Now().Add(15 * time.Minute)
+	repo.On("RedeemQuote", mock.Anything, mock.Anything).Return(true, nil)
+	repo.On("SetProductsToReserved", mock.Anything, mock.Anything, 15*time.Minute, []int{2}, quoteRequest.Latitude, quoteRequest.Longitude, mock.Anything).
+		Return(reserveWith(stockQuoted, expiresAt))
+
+	resp, err := s.RedeemReservationQuote(context.Background(), q.QuoteToken)
+	require.NoError(t, err)
+	assert.Equal(t, q.Items, resp.Items)
+	assert.Equal(t, expiresAt, resp.ExpiresAt)
+	assert.NotEqual(t, uuid.Nil, resp.ReservationID)
+	repo.AssertExpectations(t)
+}
+
+func TestService_RedeemReservationQuoteExpired(t *testing.T) {
+	repo := new(mockrepository.RepositoryMock)
+	s := newQuoteService(repo, -time.Minute)
+	q := quote(t, s, repo)
+
+	_, err := s.RedeemReservationQuote(context.Background(), q.QuoteToken)
+	assert.ErrorIs(t, err, models.ErrQuoteStale)
+	repo.AssertNotCalled(t, "RedeemQuote", mock.Anything, mock.Anything)
+}
+
+func TestService_RedeemReservationQuoteRedeemed(t *testing.T) {
+	repo := new(mockrepository.RepositoryMock)
+	s := newQuoteService(repo, time.Minute)
+	q := quote(t, s, repo)
+
+	repo.On("RedeemQuote", mock.Anything, mock.Anything).Return(false, nil)
+
+	_, err := s.RedeemReservationQuote(context.Background(), q.QuoteToken)
+	assert.ErrorIs(t, err, models.ErrConflict)
+	repo.AssertNotCalled(t, "SetProductsToReserved", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestService_RedeemReservationQuoteStockChanged(t *testing.T) {
+	repo := new(mockrepository.RepositoryMock)
+	s := newQuoteService(repo, time.Minute)
+	q := quote(t, s, repo)
+
+	var quoteID uuid.UUID
+	repo.On("RedeemQuote", mock.Anything, mock.MatchedBy(func(id uuid.UUID) bool {
+		quoteID = id
+		return true
+	})).Return(true, nil)
+	repo.On("SetProductsToReserved", mock.Anything, mock.Anything, mock.Anything, []int{2}, mock.Anything, mock.Anything, mock.Anything).
+		Return(reserveWith([]models.WarehouseProduct{{ID: 5, ProductID: 2, WarehouseID: 1, Quantity: 9, Distance: 1000}}, time.Time{}))
+	// the quote can be redeemed again
+	repo.On("DeleteRedeemedQuote", mock.Anything, mock.MatchedBy(func(id uuid.UUID) bool {
+		return id == quoteID
+	})).Return(nil)
+
+	_, err := s.RedeemReservationQuote(context.Background(), q.QuoteToken)
+	assert.ErrorIs(t, err, models.ErrQuoteStale)
+	repo.AssertExpectations(t)
+}
+
+func TestService_RedeemReservationQuoteInvalidToken(t *testing.T) {
+	s := service.NewService(nil, models.ConfigReservation{QuoteSecret: []byte("secret")}, models.ConfigIdempotency{}, nil)
+
+	for _, token := range []string{
+		"not a token",
+		// signed with another secret
+		"eyJleHBpcmVzX2F0IjoiMjAzMC0wMS0wMVQwMDowMDowMFoiLCJsaW5lcyI6W10sIml0ZW1zIjpbXX0.c2lnbmF0dXJl",
+	} {
+		_, err := s.RedeemReservationQuote(context.Background(), token)
+		assert.ErrorIs(t, err, models.ErrValidation, token)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+)
+
+// CreateReceipt adds received products to the stock on hand of the warehouse. Items with the same
+// part number are merged.
+func (s *Service) CreateReceipt(ctx context.Context, warehouseID int, req models.CreateReceiptRequest) (models.Receipt, error) {
+	items, quantities, err := s.stockQuantities(ctx, req.Items)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+
+	receipt, err := s.repos.CreateReceipt(ctx, warehouseID, quantities)
+	if err != nil {
+		return models.Receipt{}, fmt.Errorf("error to create receipt: %w", err)
+	}
+	receipt.Items = items
+	return receipt, nil
+}
+
+// stockQuantities merges items with the same part number and returns them with their quantities
+// by product ids. It fails with models.ErrUnknownPartNumber if any of the products does not exist.
+func (s *Service) stockQuantities(ctx context.Context, items []models.StockItem) ([]models.StockItem, map[int]int, error) {
+	var (
+		merged      []models.StockItem
+		index       = make(map[string]int, len(items))
+		partNumbers []string
+	)
+	for _, item := range items {
+		if i, ok := index[item.PartNumber]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.PartNumber] = len(merged)
+		merged = append(merged, item)
+		partNumbers = append(partNumbers, item.PartNumber)
+	}
+
+	productIDs, err := s.productIDs(ctx, partNumbers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	quantities := make(map[int]int, len(merged))
+	for _, item := range merged {
+		quantities[productIDs[item.PartNumber]] = item.Quantity
+	}
+	return merged, quantities, nil
+}
@@ -2,91 +2,425 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Hymiside/lamoda-api/pkg/models"
 	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 )
 
+// expirationBatchSize limits the number of reserved products expired in one transaction.
+const expirationBatchSize = 100
+
+// idempotencyPurgeInterval is how often idempotency keys older than the TTL are deleted.
+const idempotencyPurgeInterval = time.Hour
+
+//go:generate mockery --name=repository --output=../../mock/repository --outpkg=repository_mock --filename=repository_mock.go
 type repository interface {
-	Products(ctx context.Context) ([]models.Product, error)
-	ProductsIDsByPartNumbers(ctx context.Context, partNumbers []string) ([]int, error)
-	AvailabilityProductsByWarehouseID(ctx context.Context, warehouseID int) ([]models.AvailabilityProducts, error)
+	Products(ctx context.Context, filter models.ProductsFilter) ([]models.Product, error)
+	ProductsByPartNumbers(ctx context.Context, partNumbers []string) ([]models.Product, error)
 	WarehousesByProductIDs(ctx context.Context, productIDs []int, lat, long float64) ([]models.WarehouseProduct, error)
+	AvailabilityProductsByWarehouseID(ctx context.Context, warehouseID int) ([]models.AvailabilityProducts, error)
+	AvailabilityByProductID(ctx context.Context, productID int) (models.ProductAvailability, error)
+
+	SetProductsToReserved(
+		ctx context.Context,
+		reservationID uuid.UUID,
+		ttl time.Duration,
+		productIDs []int,
+		lat, long float64,
+		plan func(warehouses []models.WarehouseProduct) ([]models.ReservationProducts, error),
+	) (time.Time, error)
+	SetReservedProductsStatus(ctx context.Context, reservationID uuid.UUID, warehouseProductIDs []int, status models.ReservationStatus) error
+	ExpireReservedProducts(ctx context.Context, limit int) (int, error)
+	ReservedProductsByReservationID(ctx context.Context, reservationID uuid.UUID) ([]models.ReservationLine, error)
+
+	ProductByPartNumber(ctx context.Context, partNumber string) (models.Product, error)
+	CreateProduct(ctx context.Context, product models.Product) (models.Product, error)
+	UpdateProduct(ctx context.Context, partNumber string, req models.UpdateProductRequest) (models.Product, error)
+	ArchiveProduct(ctx context.Context, partNumber string) error
 
-	SetProductsToReserved(ctx context.Context, reservationID uuid.UUID, warehousesProducts map[int]int) (uuid.UUID, error)
-	SetProductsToConfirmedOrCanceledByProductIDs(ctx context.Context, status int, reservationData models.CancelORConfirmProductsRequest) error
-	SetProductsToConfirmedOrCanceled(ctx context.Context, status int, reservationID uuid.UUID) error
+	Warehouses(ctx context.Context) ([]models.Warehouse, error)
+	WarehouseByID(ctx context.Context, warehouseID int) (models.Warehouse, error)
+	NearestWarehouses(ctx context.Context, lat, long float64, productIDs []int, limit int) ([]models.NearestWarehouse, error)
+	CreateWarehouse(ctx context.Context, warehouse models.Warehouse) (models.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouseID int, req models.UpdateWarehouseRequest) (models.Warehouse, error)
+	DeleteWarehouse(ctx context.Context, warehouseID int) error
+	StockMovements(ctx context.Context, filter models.StockMovementsFilter) ([]models.StockMovement, error)
+	CreateReceipt(ctx context.Context, warehouseID int, quantities map[int]int) (models.Receipt, error)
+	CreateAdjustment(ctx context.Context, warehouseID int, reason models.AdjustmentReason, counted map[int]int) (models.Adjustment, error)
+	CreateTransfer(ctx context.Context, transfer models.Transfer, quantities map[int]int) (models.Transfer, error)
+	TransferByID(ctx context.Context, transferID int) (models.Transfer, error)
+	SetTransferStatus(ctx context.Context, transferID int, status models.TransferStatus) error
+	SetReorderPoints(ctx context.Context, warehouseID int, points map[int]int) error
+	ReorderPoints(ctx context.Context, warehouseID int) ([]models.ReorderPoint, error)
+	UpdateLowStockAlerts(ctx context.Context) error
+	ClaimUnnotifiedLowStockAlerts(ctx context.Context, lease time.Duration) ([]models.LowStockAlert, error)
+	SetLowStockAlertsNotified(ctx context.Context, ids []int) error
+	ReleaseLowStockAlerts(ctx context.Context, ids []int) error
+	LowStockAlerts(ctx context.Context, warehouseID int) ([]models.LowStockAlert, error)
+
+	CreateIdempotencyKey(ctx context.Context, key models.IdempotencyKey, lease time.Duration) (bool, error)
+	IdempotencyKey(ctx context.Context, key, scope string) (models.IdempotencyKey, error)
+	RenewIdempotencyKey(ctx context.Context, key models.IdempotencyKey) (bool, error)
+	SetIdempotencyKeyResponse(ctx context.Context, key models.IdempotencyKey) error
+	DeleteIdempotencyKey(ctx context.Context, key models.IdempotencyKey) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, ttl time.Duration) (int, error)
+
+	RedeemQuote(ctx context.Context, quoteID uuid.UUID) (bool, error)
+	DeleteRedeemedQuote(ctx context.Context, quoteID uuid.UUID) error
+	DeleteExpiredRedeemedQuotes(ctx context.Context, ttl time.Duration) (int, error)
 }
 
 type Service struct {
-	repos repository
+	repos       repository
+	cfg         models.ConfigReservation
+	idempotency models.ConfigIdempotency
+	notifier    notifier
 }
 
-func NewService(repos repository) *Service {
-	return &Service{repos: repos}
+func NewService(repos repository, cfg models.ConfigReservation, idempotency models.ConfigIdempotency, notifier notifier) *Service {
+	if cfg.Strategy == "" {
+		cfg.Strategy = models.StrategyNearest
+	}
+	if idempotency.Lease == 0 {
+		idempotency.Lease = models.DefaultIdempotencyLease
+	}
+	if idempotency.TTL == 0 {
+		idempotency.TTL = models.DefaultIdempotencyTTL
+	}
+	return &Service{repos: repos, cfg: cfg, idempotency: idempotency, notifier: notifier}
 }
 
-func (s *Service) ReservationProducts(ctx context.Context, req models.ReservationProductsRequest) (uuid.UUID, error) {
-	productIDs, err := s.repos.ProductsIDsByPartNumbers(ctx, req.PartNumbers)
+func (s *Service) ReservationProducts(ctx context.Context, req models.ReservationProductsRequest) (models.ReservationProductsResponse, error) {
+	rp, err := s.newReservationPlan(ctx, req)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error to get products: %v", err)
+		return models.ReservationProductsResponse{}, err
 	}
 
-	if len(productIDs) == 0 {
-		return uuid.Nil, fmt.Errorf("products not found")
+	if len(rp.productIDs) == 0 {
+		_, err := rp.plan(nil)
+		return models.ReservationProductsResponse{}, err
 	}
 
-	warehousesProducts, err := s.repos.WarehousesByProductIDs(ctx, productIDs, req.Latitude, req.Longitude)
+	reservationID := uuid.New()
+	expiresAt, err := s.repos.SetProductsToReserved(ctx, reservationID, s.holdTTL(req.HoldTTL), rp.productIDs, req.Latitude, req.Longitude, rp.plan)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error to get warehouses: %v", err)
+		return models.ReservationProductsResponse{}, fmt.Errorf("error to set products to reserved: %w", err)
 	}
+	return models.ReservationProductsResponse{ReservationID: reservationID, ExpiresAt: expiresAt, Items: rp.items}, nil
+}
 
-	if len(warehousesProducts) == 0 {
-		return uuid.Nil, fmt.Errorf("warehousesProducts not found")
+// holdTTL returns the requested hold time of a reservation in seconds or the default one.
+func (s *Service) holdTTL(seconds int) time.Duration {
+	if seconds > 0 {
+		return time.Duration(seconds) * time.Second
 	}
+	return s.cfg.TTL
+}
+
+// reservationPlan allocates the requested items. Plan is called with the stock of the products
+// ordered by the distance and sets items to the outcome of every requested line.
+type reservationPlan struct {
+	productIDs []int
+	items      []models.ReservedItem
+	plan       func(warehouses []models.WarehouseProduct) ([]models.ReservationProducts, error)
+}
 
-	reservation := make(map[int]int)
-	for _, v := range warehousesProducts {
-		if _, ok := reservation[v.ProductID]; !ok {
-			reservation[v.ProductID] = v.WarehouseID
+func (s *Service) newReservationPlan(ctx context.Context, req models.ReservationProductsRequest) (*reservationPlan, error) {
+	partNumbers, quantities := make([]string, 0, len(req.Items)), make(map[string]int, len(req.Items))
+	for _, item := range req.Items {
+		if _, ok := quantities[item.PartNumber]; !ok {
+			partNumbers = append(partNumbers, item.PartNumber)
 		}
+		quantities[item.PartNumber] += item.Quantity
 	}
 
-	reservationID, err := s.repos.SetProductsToReserved(ctx, uuid.New(), reservation)
+	products, err := s.repos.ProductsByPartNumbers(ctx, partNumbers)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error to set products to reserved: %v", err)
+		return nil, fmt.Errorf("error to get products: %w", err)
+	}
+
+	rp := &reservationPlan{productIDs: make([]int, len(products))}
+	productsByPartNumber := make(map[string]models.Product, len(products))
+	for i, p := range products {
+		productsByPartNumber[p.PartNumber] = p
+		rp.productIDs[i] = p.ID
 	}
-	return reservationID, nil
-}
 
-func (s *Service) Products(ctx context.Context) ([]models.Product, error) {
-	products, err := s.repos.Products(ctx)
+	strategy := s.cfg.Strategy
+	if req.Strategy != "" {
+		strategy = req.Strategy
+	}
+
+	allocator, err := NewAllocator(strategy, s.cfg)
 	if err != nil {
-		return nil, fmt.Errorf("error to get products: %v", err)
+		return nil, fmt.Errorf("error to create allocator: %w", err)
+	}
+
+	productQuantities := make(map[int]int, len(products))
+	for _, p := range products {
+		productQuantities[p.ID] = quantities[p.PartNumber]
+	}
+
+	rp.plan = func(warehouses []models.WarehouseProduct) ([]models.ReservationProducts, error) {
+		allocation := allocator.Allocate(productQuantities, warehouses)
+
+		var (
+			reservation []models.ReservationProducts
+			failed      bool
+		)
+		rp.items = make([]models.ReservedItem, len(partNumbers))
+		for i, partNumber := range partNumbers {
+			item := models.ReservedItem{PartNumber: partNumber, Quantity: quantities[partNumber], Status: models.ItemUnknown}
+			if p, ok := productsByPartNumber[partNumber]; ok {
+				item.Status = models.ItemUnavailable
+				if lines, ok := allocation[p.ID]; ok {
+					item.Status = models.ItemReserved
+					for _, line := range lines {
+						reservation = append(reservation, line)
+						item.Warehouses = append(item.Warehouses, models.ReservedWarehouse{
+							WarehouseID: line.WarehouseID,
+							Quantity:    line.Quantity,
+							Distance:    line.Distance,
+						})
+					}
+				}
+			}
+
+			failed = failed || item.Status != models.ItemReserved
+			rp.items[i] = item
+		}
+
+		if len(reservation) == 0 || failed && req.Policy != models.PolicyBestEffort {
+			return nil, &models.AllocationError{Items: rp.items}
+		}
+		return reservation, nil
 	}
-	return products, nil
+	return rp, nil
 }
 
 func (s *Service) AvailabilityProductsByWarehouseID(ctx context.Context, warehouseID int) ([]models.AvailabilityProducts, error) {
 	reservedProducts, err := s.repos.AvailabilityProductsByWarehouseID(ctx, warehouseID)
 	if err != nil {
-		return nil, fmt.Errorf("error to get reserved products: %v", err)
+		return nil, fmt.Errorf("error to get reserved products: %w", err)
 	}
 	return reservedProducts, nil
 }
 
-func (s *Service) ConfirmOrCancelReservedProducts(ctx context.Context, status int, req models.CancelORConfirmProductsRequest) error {
+func (s *Service) Reservation(ctx context.Context, reservationID uuid.UUID) (models.Reservation, error) {
+	lines, err := s.repos.ReservedProductsByReservationID(ctx, reservationID)
+	if err != nil {
+		return models.Reservation{}, fmt.Errorf("error to get reserved products: %w", err)
+	}
+
+	if len(lines) == 0 {
+		return models.Reservation{}, models.ErrNotFound.WithDetail("reservation %s", reservationID)
+	}
+
+	status := lines[0].Status.String()
+	for _, line := range lines[1:] {
+		if line.Status != lines[0].Status {
+			status = "mixed"
+			break
+		}
+	}
+	return models.Reservation{ReservationID: reservationID, Status: status, Lines: lines}, nil
+}
+
+// ChangeReservationStatus moves the whole reservation, or only its products listed in req.PartNumbers,
+// to status. If any of the products can not be moved to status, nothing is changed and
+// *models.StatusConflictError naming them is returned.
+func (s *Service) ChangeReservationStatus(ctx context.Context, status models.ReservationStatus, req models.CancelORConfirmProductsRequest) error {
+	lines, err := s.repos.ReservedProductsByReservationID(ctx, req.ReservationID)
+	if err != nil {
+		return fmt.Errorf("error to get reserved products: %w", err)
+	}
+
+	if len(lines) == 0 {
+		return models.ErrNotFound.WithDetail("reservation %s", req.ReservationID)
+	}
+
+	if req.PartNumbers != nil {
+		requested := make(map[string]bool, len(req.PartNumbers))
+		for _, partNumber := range req.PartNumbers {
+			requested[partNumber] = true
+		}
+
+		selected := make([]models.ReservationLine, 0, len(lines))
+		for _, line := range lines {
+			if requested[line.PartNumber] {
+				selected = append(selected, line)
+				delete(requested, line.PartNumber)
+			}
+		}
+
+		if len(requested) > 0 {
+			missing := make([]string, 0, len(requested))
+			for partNumber := range requested {
+				missing = append(missing, partNumber)
+			}
+			sort.Strings(missing)
+			return models.ErrNotFound.WithDetail("products %s in reservation %s", strings.Join(missing, ", "), req.ReservationID)
+		}
+		lines = selected
+	}
+
+	var conflicts []models.ReservationLine
+	warehouseProductIDs := make([]int, len(lines))
+	for i, line := range lines {
+		if !line.Status.CanTransitionTo(status) {
+			conflicts = append(conflicts, line)
+		}
+		warehouseProductIDs[i] = line.WarehouseProductID
+	}
+
+	if len(conflicts) > 0 {
+		return &models.StatusConflictError{Status: status, Lines: conflicts}
+	}
+
+	if err := s.repos.SetReservedProductsStatus(ctx, req.ReservationID, warehouseProductIDs, status); err != nil {
+		return fmt.Errorf("error to set status of reserved products: %w", err)
+	}
+	return nil
+}
+
+// BeginIdempotentRequest registers the request under its idempotency key, held by key.LeaseID. If the key
+// has already been used for the same request, the stored response is returned and the request must not be
+// handled again. A request whose lease has not been renewed for longer than the lease is considered abandoned
+// and its key is taken over.
+func (s *Service) BeginIdempotentRequest(ctx context.Context, key models.IdempotencyKey) (*models.IdempotentResponse, error) {
+	created, err := s.repos.CreateIdempotencyKey(ctx, key, s.idempotency.Lease)
+	if err != nil {
+		return nil, fmt.Errorf("error to create idempotency key: %w", err)
+	}
+
+	if created {
+		return nil, nil
+	}
+
+	stored, err := s.repos.IdempotencyKey(ctx, key.Key, key.Scope)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, models.ErrConflict.WithDetail("request with idempotency key %q is in progress", key.Key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error to get idempotency key: %w", err)
+	}
+
+	if stored.RequestHash != key.RequestHash {
+		return nil, models.ErrIdempotencyKeyReused.WithDetail("idempotency key %q", key.Key)
+	}
+
+	if stored.Response == nil {
+		return nil, models.ErrConflict.WithDetail("request with idempotency key %q is in progress", key.Key)
+	}
+	return stored.Response, nil
+}
+
+// KeepIdempotentRequest renews the lease of the request registered by BeginIdempotentRequest every third
+// of the lease until ctx is done, so the key is not taken over while the request is handled. It returns
+// an error if the key has been taken over anyway, e.g. after the renewals have failed for the whole lease;
+// then the request must not be completed, as the other request handles it.
+func (s *Service) KeepIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error {
+	ticker := time.NewTicker(s.idempotency.Lease / 3)
+	defer ticker.Stop()
 
-	if req.PartNumbers == nil {
-		if err := s.repos.SetProductsToConfirmedOrCanceled(ctx, status, req.ReservationID); err != nil {
-			return fmt.Errorf("error to set products to confirmed: %v", err)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			renewed, err := s.repos.RenewIdempotencyKey(ctx, key)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				// the lease is still held, the renewal is retried by the next tick
+				log.Errorf("error to renew idempotency key: %v", err)
+				continue
+			}
+			if !renewed {
+				return models.ErrConflict.WithDetail("idempotency key %q has been taken over", key.Key)
+			}
 		}
-		return nil
 	}
+}
+
+// FinishIdempotentRequest stores the response of the request registered by BeginIdempotentRequest.
+func (s *Service) FinishIdempotentRequest(ctx context.Context, key models.IdempotencyKey, resp models.IdempotentResponse) error {
+	key.Response = &resp
+	if err := s.repos.SetIdempotencyKeyResponse(ctx, key); err != nil {
+		return fmt.Errorf("error to set idempotency key response: %w", err)
+	}
+	return nil
+}
 
-	if err := s.repos.SetProductsToConfirmedOrCanceledByProductIDs(ctx, status, req); err != nil {
-		return fmt.Errorf("error to set products to confirmed: %v", err)
+// AbortIdempotentRequest releases the key of the request registered by BeginIdempotentRequest,
+// so the request can be retried with the same key.
+func (s *Service) AbortIdempotentRequest(ctx context.Context, key models.IdempotencyKey) error {
+	if err := s.repos.DeleteIdempotencyKey(ctx, key); err != nil {
+		return fmt.Errorf("error to delete idempotency key: %w", err)
 	}
 	return nil
 }
+
+// RunIdempotencyKeysPurge deletes idempotency keys older than the TTL every idempotencyPurgeInterval until ctx is done.
+func (s *Service) RunIdempotencyKeysPurge(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.repos.DeleteExpiredIdempotencyKeys(ctx, s.idempotency.TTL)
+			if err != nil {
+				log.Errorf("error to purge idempotency keys: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Infof("%d idempotency keys purged", purged)
+			}
+		}
+	}
+}
+
+// RunReservationsExpiration releases overdue reservations every ExpirationInterval until ctx is done.
+func (s *Service) RunReservationsExpiration(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ExpirationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ExpireReservations(ctx); err != nil {
+				log.Errorf("error to expire reservations: %v", err)
+			}
+			s.purgeRedeemedQuotes(ctx)
+		}
+	}
+}
+
+func (s *Service) ExpireReservations(ctx context.Context) error {
+	for {
+		expired, err := s.repos.ExpireReservedProducts(ctx, expirationBatchSize)
+		if err != nil {
+			return fmt.Errorf("error to expire reserved products: %w", err)
+		}
+
+		if expired > 0 {
+			log.Infof("%d reserved products expired", expired)
+		}
+
+		if expired < expirationBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockrepository "github.com/Hymiside/lamoda-api/mock/repository"
+	"github.com/Hymiside/lamoda-api/pkg/models"
+	"github.com/Hymiside/lamoda-api/pkg/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_KeepIdempotentRequest(t *testing.T) {
+	key := models.IdempotencyKey{Key: "key-1", Scope: "POST /reservation-products", LeaseID: uuid.New()}
+
+	repo := new(mockrepository.RepositoryMock)
+	repo.On("RenewIdempotencyKey", mock.Anything, key).Return(false, errors.New("connection refused")).Once()
+	repo.On("RenewIdempotencyKey", mock.Anything, key).Return(true, nil).Once()
+	repo.On("RenewIdempotencyKey", mock.Anything, key).Return(false, nil).Once()
+
+	s := service.NewService(repo, models.ConfigReservation{}, models.ConfigIdempotency{Lease: 30 * time.Millisecond}, nil)
+
+	// a failed renewal is retried, the key is kept until it is taken over
+	err := s.KeepIdempotentRequest(context.Background(), key)
+	assert.ErrorIs(t, err, models.ErrConflict)
+	repo.AssertExpectations(t)
+}
+
+func TestService_KeepIdempotentRequestDone(t *testing.T) {
+	repo := new(mockrepository.RepositoryMock)
+	repo.On("RenewIdempotencyKey", mock.Anything, mock.Anything).Return(true, nil).Maybe()
+
+	s := service.NewService(repo, models.ConfigReservation{}, models.ConfigIdempotency{Lease: 30 * time.Millisecond}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, s.KeepIdempotentRequest(ctx, models.IdempotencyKey{Key: "key-1"}))
+	repo.AssertExpectations(t)
+}
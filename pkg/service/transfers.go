@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+)
+
+// CreateTransfer creates a transfer of products between warehouses. Items with the same part number are merged.
+func (s *Service) CreateTransfer(ctx context.Context, req models.CreateTransferRequest) (models.Transfer, error) {
+	if req.SourceWarehouseID == req.DestinationWarehouseID {
+		return models.Transfer{}, &models.ValidationError{Fields: []models.FieldError{{
+			Field:   "destination_warehouse_id",
+			Rule:    "nefield",
+			Message: "destination_warehouse_id must differ from source_warehouse_id",
+		}}}
+	}
+
+	items, quantities, err := s.stockQuantities(ctx, req.Items)
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	transfer, err := s.repos.CreateTransfer(ctx, models.Transfer{
+		SourceWarehouseID:      req.SourceWarehouseID,
+		DestinationWarehouseID: req.DestinationWarehouseID,
+	}, quantities)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("error to create transfer: %w", err)
+	}
+	transfer.Items = items
+	return transfer, nil
+}
+
+func (s *Service) Transfer(ctx context.Context, transferID int) (models.Transfer, error) {
+	transfer, err := s.repos.TransferByID(ctx, transferID)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("error to get transfer: %w", err)
+	}
+	return transfer, nil
+}
+
+// ChangeTransferStatus ships or receives the transfer and returns it.
+func (s *Service) ChangeTransferStatus(ctx context.Context, transferID int, status models.TransferStatus) (models.Transfer, error) {
+	if err := s.repos.SetTransferStatus(ctx, transferID, status); err != nil {
+		return models.Transfer{}, fmt.Errorf("error to set status of transfer: %w", err)
+	}
+	return s.Transfer(ctx, transferID)
+}
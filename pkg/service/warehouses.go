@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Hymiside/lamoda-api/pkg/models"
+)
+
+func (s *Service) Warehouses(ctx context.Context) ([]models.Warehouse, error) {
+	warehouses, err := s.repos.Warehouses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error to get warehouses: %w", err)
+	}
+	return warehouses, nil
+}
+
+func (s *Service) Warehouse(ctx context.Context, warehouseID int) (models.Warehouse, error) {
+	warehouse, err := s.repos.WarehouseByID(ctx, warehouseID)
+	if err != nil {
+		return models.Warehouse{}, fmt.Errorf("error to get warehouse: %w", err)
+	}
+	return warehouse, nil
+}
+
+// NearestWarehouses returns available warehouses ordered by the distance from the requested point
+// with their stock of the requested products.
+func (s *Service) NearestWarehouses(ctx context.Context, req models.NearestWarehousesRequest) ([]models.NearestWarehouse, error) {
+	productIDs := make([]int, 0, len(req.PartNumbers))
+	if len(req.PartNumbers) > 0 {
+		ids, err := s.productIDs(ctx, req.PartNumbers)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			productIDs = append(productIDs, id)
+		}
+	}
+
+	warehouses, err := s.repos.NearestWarehouses(ctx, *req.Latitude, *req.Longitude, productIDs, req.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error to get nearest warehouses: %w", err)
+	}
+	return warehouses, nil
+}
+
+func (s *Service) CreateWarehouse(ctx context.Context, req models.CreateWarehouseRequest) (models.Warehouse, error) {
+	warehouse, err := s.repos.CreateWarehouse(ctx, models.Warehouse{
+		Title:     req.Title,
+		Available: req.Available,
+		Latitude:  *req.Latitude,
+		Longitude: *req.Longitude,
+	})
+	if err != nil {
+		return models.Warehouse{}, fmt.Errorf("error to create warehouse: %w", err)
+	}
+	return warehouse, nil
+}
+
+func (s *Service) UpdateWarehouse(ctx context.Context, warehouseID int, req models.UpdateWarehouseRequest) (models.Warehouse, error) {
+	warehouse, err := s.repos.UpdateWarehouse(ctx, warehouseID, req)
+	if err != nil {
+		return models.Warehouse{}, fmt.Errorf("error to update warehouse: %w", err)
+	}
+	return warehouse, nil
+}
+
+func (s *Service) DeleteWarehouse(ctx context.Context, warehouseID int) error {
+	if err := s.repos.DeleteWarehouse(ctx, warehouseID); err != nil {
+		return fmt.Errorf("error to delete warehouse: %w", err)
+	}
+	return nil
+}
+
+// productIDs returns ids of products by their part numbers. It fails with models.ErrUnknownPartNumber
+// if any of the products does not exist or is archived.
+func (s *Service) productIDs(ctx context.Context, partNumbers []string) (map[string]int, error) {
+	products, err := s.repos.ProductsByPartNumbers(ctx, partNumbers)
+	if err != nil {
+		return nil, fmt.Errorf("error to get products: %w", err)
+	}
+
+	ids := make(map[string]int, len(products))
+	for _, p := range products {
+		ids[p.PartNumber] = p.ID
+	}
+
+	var unknown []string
+	for _, partNumber := range partNumbers {
+		if _, ok := ids[partNumber]; !ok {
+			unknown = append(unknown, partNumber)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, models.ErrUnknownPartNumber.WithDetail("%s", strings.Join(unknown, ", "))
+	}
+	return ids, nil
+}